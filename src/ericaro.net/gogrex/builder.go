@@ -0,0 +1,108 @@
+package gogrex
+
+import (
+	"context"
+)
+
+// The builder functions are the public counterpart of the operators used by ParseGrex.
+// They are useful to build grexes from other descriptions than a gogrex expression (schemas, other regexp syntax).
+// Operands are never modified: every function returns a new grex. Operands must share the same Manager.
+
+//Terminal returns a new Grex that accepts the single symbol name.
+func Terminal(m Manager, name string) *Grex {
+	g := NewGrex(m)
+	t := m.NewEdge(name)
+	s1 := m.NewVertex()
+	s2 := m.NewVertex()
+	g.graph.AddEdge(t, s1, s2)
+	g.in = s1
+	g.outs[s2] = nil
+	return g
+}
+
+//Empty returns a new Grex that only accepts the empty sequence.
+func Empty(m Manager) *Grex {
+	return empty(m)
+}
+
+//Seq returns a new Grex result of "this, that"
+func Seq(this, that *Grex) *Grex {
+	return seq(this, that)
+}
+
+//Sel returns a new Grex result of "this | that"
+func Sel(this, that *Grex) *Grex {
+	return sel(this, that)
+}
+
+//Plus returns a new Grex result of "(this)+"
+func Plus(this *Grex) *Grex {
+	return plus(this)
+}
+
+//Opt returns a new Grex result of "(this)?"
+func Opt(this *Grex) *Grex {
+	return opt(this)
+}
+
+//Star returns a new Grex result of "(this)*"
+func Star(this *Grex) *Grex {
+	return star(this)
+}
+
+//MaxRepeat is the largest bound of Repeat, as in Go regexps.
+const MaxRepeat = 1000
+
+//MaxRepeatSize is the largest number of vertices, and of edges, created by Repeat, copies included: every repetition
+// is a copy of the graph, so repetitions of repetitions multiply.
+const MaxRepeatSize = 1 << 18
+
+//Repeat returns a new Grex that accepts from min to max repetitions of this. A negative max means unbounded.
+// It panics if min is negative or greater than a non negative max, if a bound is greater than MaxRepeat, or if the
+// repetition is larger than MaxRepeatSize.
+func Repeat(this *Grex, min, max int) *Grex {
+	if min < 0 || max >= 0 && min > max {
+		panic("gogrex: invalid repetition bounds")
+	}
+	if min > MaxRepeat || max > MaxRepeat {
+		panic("gogrex: repetition bounds greater than MaxRepeat")
+	}
+	g, err := repeat(this, min, max)
+	if err != nil {
+		panic("gogrex: repetition larger than MaxRepeatSize")
+	}
+	return g
+}
+
+//repeat builds min mandatory copies of this, followed by either (this)* or (this, (this, (this)?)?)? for max - min
+// times. Copies are appended in one pass, within MaxRepeatSize.
+func repeat(this *Grex, min, max int) (*Grex, error) {
+	l := &limiter{ctx: context.Background(), limits: Limits{MaxVertices: MaxRepeatSize, MaxEdges: MaxRepeatSize}}
+	var operands []*Grex
+	for i := 0; i < min; i++ {
+		operands = append(operands, this)
+	}
+	switch {
+	case max < 0:
+		tail, err := repeatLimited(this, OpStar, l)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, tail)
+	case max > min:
+		copies := make([]*Grex, max-min)
+		for i := range copies {
+			copies[i] = this
+		}
+		tail, err := seqLimited(copies, true, l)
+		if err != nil {
+			return nil, err
+		}
+		tail.outs[tail.in] = nil
+		operands = append(operands, tail)
+	}
+	if len(operands) == 0 {
+		return empty(this.manager), nil
+	}
+	return seqLimited(operands, false, l)
+}
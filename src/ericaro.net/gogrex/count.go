@@ -0,0 +1,166 @@
+package gogrex
+
+import (
+	"math/big"
+)
+
+// On a determinized grex, every accepted sequence is a distinct path from the start to an accepting state.
+// Counting sequences of length n is counting such paths, by dynamic programming over the length.
+
+//Count returns the number of distinct sequences of length n accepted by the grex, zero for a negative length.
+func (g *Grex) Count(n int) *big.Int {
+	if n < 0 {
+		return new(big.Int)
+	}
+	return g.CountUpTo(n)[n]
+}
+
+//CountUpTo returns the number of distinct accepted sequences of every length from 0 to n: the first n+1 coefficients
+// of the generating function of the language. It is empty for a negative n.
+func (g *Grex) CountUpTo(n int) []*big.Int {
+	if n < 0 {
+		return []*big.Int{}
+	}
+	d := g.determinize()
+	counts := make([]*big.Int, n+1)
+	// paths[s] is the number of paths of the current length from the start to s
+	paths := make([]*big.Int, len(d.next))
+	for s := range paths {
+		paths[s] = new(big.Int)
+	}
+	paths[0].SetInt64(1)
+	for length := 0; length <= n; length++ {
+		counts[length] = new(big.Int)
+		for s, p := range paths {
+			if d.accept[s] {
+				counts[length].Add(counts[length], p)
+			}
+		}
+		if length == n {
+			break
+		}
+		next := make([]*big.Int, len(paths))
+		for s := range next {
+			next[s] = new(big.Int)
+		}
+		for s, p := range paths {
+			if p.Sign() == 0 {
+				continue
+			}
+			for _, t := range d.next[s] {
+				if t >= 0 {
+					next[t].Add(next[t], p)
+				}
+			}
+		}
+		paths = next
+	}
+	return counts
+}
+
+//IsFinite tells if the grex accepts a finite number of sequences.
+func (g *Grex) IsFinite() bool {
+	_, finite := g.MaxLength()
+	return finite
+}
+
+//MaxLength returns the length of the longest accepted sequence, and false if there is none because the language is
+// infinite. It returns -1 if the grex accepts nothing at all.
+func (g *Grex) MaxLength() (max int, finite bool) {
+	d := g.determinize()
+	live := d.live()
+	// longest path over live states, by depth first search. Meeting a state being visited means a cycle.
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	status := make([]int, len(d.next))
+	longest := make([]int, len(d.next)) // longest path from a state to an accepting one
+	var visit func(s int) bool
+	visit = func(s int) bool {
+		status[s] = visiting
+		longest[s] = -1
+		if d.accept[s] {
+			longest[s] = 0
+		}
+		for _, t := range d.next[s] {
+			if t < 0 || !live[t] {
+				continue
+			}
+			switch status[t] {
+			case visiting:
+				return false
+			case unvisited:
+				if !visit(t) {
+					return false
+				}
+			}
+			if longest[t]+1 > longest[s] {
+				longest[s] = longest[t] + 1
+			}
+		}
+		status[s] = visited
+		return true
+	}
+	if !live[0] {
+		return -1, true
+	}
+	if !visit(0) {
+		return 0, false
+	}
+	return longest[0], true
+}
+
+//Sequences returns the accepted sequences of at most maxLength symbols, shortest first, then in lexicographic order.
+// It stops after 'limit' sequences, unless limit is negative.
+func (g *Grex) Sequences(maxLength, limit int) [][]string {
+	d := g.determinize()
+	// complete[r][s] tells if the state s accepts a sequence of exactly r symbols. Sequences of each length are
+	// enumerated depth first, and only prefixes that can be completed are extended: every prefix leads to a sequence.
+	complete := [][]bool{d.accept}
+	var sequences [][]string
+	var symbols []string
+	var visit func(s, r int) bool // false once the limit is reached
+	visit = func(s, r int) bool {
+		if r == 0 {
+			if len(sequences) == limit {
+				return false
+			}
+			sequences = append(sequences, append(make([]string, 0, len(symbols)), symbols...))
+			return len(sequences) != limit
+		}
+		for a, t := range d.next[s] { // symbols are sorted
+			if t >= 0 && complete[r-1][t] {
+				symbols = append(symbols, d.symbols[a])
+				more := visit(t, r-1)
+				symbols = symbols[:len(symbols)-1]
+				if !more {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	for length := 0; length <= maxLength; length++ {
+		if length > 0 {
+			row, any := make([]bool, len(d.next)), false
+			for s, next := range d.next {
+				for _, t := range next {
+					if t >= 0 && complete[length-1][t] {
+						row[s], any = true, true
+						break
+					}
+				}
+			}
+			if !any { // no longer sequence either
+				break
+			}
+			complete = append(complete, row)
+		}
+		if complete[length][0] && !visit(0, length) {
+			break
+		}
+	}
+	return sequences
+}
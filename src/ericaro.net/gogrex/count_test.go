@@ -0,0 +1,88 @@
+package gogrex
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	cases := []struct {
+		exp    string
+		counts []int64 // by length, from 0
+		max    int     // -2 when infinite
+	}{
+		{"a", []int64{0, 1, 0}, 1},
+		{"a|b", []int64{0, 2, 0}, 1},
+		{"(a,b)|(a,c)", []int64{0, 0, 2, 0}, 2},
+		{"a?,b?,c?", []int64{1, 3, 3, 1, 0}, 3},
+		{"(a|b)*", []int64{1, 2, 4, 8, 16}, -2},
+		{"a*,a", []int64{0, 1, 1, 1}, -2},
+		{"(a|a)+", []int64{0, 1, 1, 1}, -2},
+	}
+	for _, c := range cases {
+		var m StringManager
+		g, err := ParseGrex(&m, c.exp)
+		if err != nil {
+			t.Fatalf("%s: %v", c.exp, err)
+		}
+		counts := g.CountUpTo(len(c.counts) - 1)
+		for n, want := range c.counts {
+			if counts[n].Int64() != want {
+				t.Errorf("%s: expected %d sequences of length %d, got %v", c.exp, want, n, counts[n])
+			}
+		}
+		if got := g.Count(len(c.counts) - 1); got.Cmp(counts[len(counts)-1]) != 0 {
+			t.Errorf("%s: Count and CountUpTo disagree", c.exp)
+		}
+		if g.Count(-1).Sign() != 0 || len(g.CountUpTo(-1)) != 0 {
+			t.Errorf("%s: there is no sequence of negative length", c.exp)
+		}
+		max, finite := g.MaxLength()
+		if finite != (c.max != -2) || finite && max != c.max {
+			t.Errorf("%s: expected max length %d, got %d %v", c.exp, c.max, max, finite)
+		}
+		// the determinized grex accepts the same sequences
+		d := g.Determinize()
+		if d.IsFinite() != finite {
+			t.Errorf("%s: the determinized grex is not equivalent", c.exp)
+		}
+		for _, s := range sequences([]string{"a", "b", "c"}, 3) {
+			if g.Match(s) != d.Match(s) {
+				t.Errorf("%s on %v: the determinized grex is not equivalent", c.exp, s)
+			}
+		}
+	}
+}
+
+func TestSequences(t *testing.T) {
+	var m StringManager
+	g, err := ParseGrex(&m, "a?, (b|c)*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fmt.Sprint(g.Sequences(2, -1))
+	if want := "[[] [a] [b] [c] [a b] [a c] [b b] [b c] [c b] [c c]]"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	if got := g.Sequences(5, 3); len(got) != 3 {
+		t.Errorf("expected 3 sequences, got %v", got)
+	}
+	if got := g.Sequences(5, 0); len(got) != 0 {
+		t.Errorf("expected no sequence, got %v", got)
+	}
+
+	// only long sequences are accepted: the prefixes that cannot end in time are not expanded
+	long, _ := ParseGrex(&m, "(a|b)*"+strings.Repeat(", c", 40))
+	got = fmt.Sprint(long.Sequences(60, 2))
+	if want := fmt.Sprint([][]string{strings.Fields(strings.Repeat("c ", 40)), strings.Fields("a" + strings.Repeat(" c", 40))}); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	if got := long.Sequences(39, -1); len(got) != 0 {
+		t.Errorf("expected no sequence, got %v", got)
+	}
+	finite, _ := ParseGrex(&m, "a, b?")
+	if got := fmt.Sprint(finite.Sequences(1000000, -1)); got != "[[a] [a b]]" {
+		t.Errorf("unexpected sequences %s", got)
+	}
+}
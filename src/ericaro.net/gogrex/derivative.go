@@ -0,0 +1,323 @@
+package gogrex
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// Brzozowski derivatives match a sequence directly on the expression, without building its graph.
+// The derivative of an expression e by a symbol a is the expression that matches every s such that "a, s" is matched by e.
+// Reading a sequence is just a matter of deriving the expression by each symbol in turn, and checking that the final
+// expression accepts the empty sequence.
+//
+// Expressions are kept normalized by smart constructors ("," and "|" flattened, "|" sorted without duplicates, ...),
+// so that there is only a finite number of distinct derivatives. They are memoized, and computed only when needed:
+// this is a lazy determinization of the expression.
+
+var (
+	nothing = &Expr{Op: OpNothing}
+	epsilon = &Expr{Op: OpEmpty}
+)
+
+//exprTable interns normalized expressions: two equal expressions are the same pointer. They are compared, sorted and
+// memoized by identity, and an expression is keyed by its operator, its name and the ids of its subs, never printed.
+type exprTable struct {
+	ids      map[*Expr]int    // interned expressions, numbered in creation order
+	nodes    map[string]*Expr // by key
+	nullable map[*Expr]bool
+}
+
+func newExprTable() *exprTable {
+	t := &exprTable{ids: make(map[*Expr]int), nodes: make(map[string]*Expr), nullable: make(map[*Expr]bool)}
+	t.intern(nothing)
+	t.intern(epsilon)
+	return t
+}
+
+//intern returns the interned expression equal to e. Its subs must be interned already.
+func (t *exprTable) intern(e *Expr) *Expr {
+	key := make([]byte, 1, 1+len(e.Name)+4*len(e.Subs))
+	key[0] = byte(e.Op)
+	for _, sub := range e.Subs {
+		key = binary.AppendUvarint(key, uint64(t.ids[sub]))
+	}
+	key = append(key, e.Name...) // only symbols have a name, and they have no subs
+	if n, ok := t.nodes[string(key)]; ok {
+		return n
+	}
+	t.ids[e] = len(t.ids)
+	t.nodes[string(key)] = e
+	switch e.Op {
+	case OpEmpty, OpStar:
+		t.nullable[e] = true
+	case OpSeq:
+		t.nullable[e] = true
+		for _, sub := range e.Subs {
+			t.nullable[e] = t.nullable[e] && t.nullable[sub]
+		}
+	case OpSel:
+		for _, sub := range e.Subs {
+			t.nullable[e] = t.nullable[e] || t.nullable[sub]
+		}
+	}
+	return e
+}
+
+//mkSeq returns the normalized "a, b, ..."
+func (t *exprTable) mkSeq(exprs ...*Expr) *Expr {
+	var subs []*Expr
+	for _, e := range exprs {
+		switch e.Op {
+		case OpNothing:
+			return nothing
+		case OpEmpty:
+		case OpSeq:
+			subs = append(subs, e.Subs...)
+		default:
+			subs = append(subs, e)
+		}
+	}
+	switch len(subs) {
+	case 0:
+		return epsilon
+	case 1:
+		return subs[0]
+	}
+	return t.intern(&Expr{Op: OpSeq, Subs: subs})
+}
+
+//mkSel returns the normalized "a | b | ...": flattened, without duplicates, sorted by id
+func (t *exprTable) mkSel(exprs ...*Expr) *Expr {
+	seen := make(map[*Expr]bool)
+	var subs []*Expr
+	add := func(e *Expr) {
+		if e.Op != OpNothing && !seen[e] {
+			seen[e] = true
+			subs = append(subs, e)
+		}
+	}
+	for _, e := range exprs {
+		if e.Op == OpSel {
+			for _, sub := range e.Subs {
+				add(sub)
+			}
+		} else {
+			add(e)
+		}
+	}
+	switch len(subs) {
+	case 0:
+		return nothing
+	case 1:
+		return subs[0]
+	}
+	sort.Slice(subs, func(i, j int) bool { return t.ids[subs[i]] < t.ids[subs[j]] })
+	return t.intern(&Expr{Op: OpSel, Subs: subs})
+}
+
+//mkStar returns the normalized "a*"
+func (t *exprTable) mkStar(a *Expr) *Expr {
+	switch a.Op {
+	case OpNothing, OpEmpty:
+		return epsilon
+	case OpStar:
+		return a
+	}
+	return t.intern(&Expr{Op: OpStar, Subs: []*Expr{a}})
+}
+
+//normalize rebuilds the expression with the smart constructors. "+" and "?" are rewritten with "*" and "|".
+func (t *exprTable) normalize(e *Expr) *Expr {
+	switch e.Op {
+	case OpSymbol:
+		return t.intern(&Expr{Op: OpSymbol, Name: e.Name})
+	case OpStar:
+		return t.mkStar(t.normalize(e.Subs[0]))
+	case OpPlus:
+		sub := t.normalize(e.Subs[0])
+		return t.mkSeq(sub, t.mkStar(sub))
+	case OpOpt:
+		return t.mkSel(epsilon, t.normalize(e.Subs[0]))
+	case OpSeq, OpSel:
+		// a chain of the same operator is normalized at once, not one level at a time
+		var subs []*Expr
+		for _, sub := range chain(e, nil) {
+			subs = append(subs, t.normalize(sub))
+		}
+		if e.Op == OpSeq {
+			return t.mkSeq(subs...)
+		}
+		return t.mkSel(subs...)
+	case OpEmpty:
+		return epsilon
+	}
+	return nothing
+}
+
+//chain appends the operands of the chain of e's operator: (a, b), c is a, b, c
+func chain(e *Expr, operands []*Expr) []*Expr {
+	for _, sub := range e.Subs {
+		if sub.Op == e.Op {
+			operands = chain(sub, operands)
+		} else {
+			operands = append(operands, sub)
+		}
+	}
+	return operands
+}
+
+//derive computes the derivative of the normalized expression by the symbol.
+func (t *exprTable) derive(e *Expr, symbol string) *Expr {
+	switch e.Op {
+	case OpSymbol:
+		if e.Name == symbol {
+			return epsilon
+		}
+	case OpStar:
+		return t.mkSeq(t.derive(e.Subs[0], symbol), e)
+	case OpSel:
+		derivatives := make([]*Expr, len(e.Subs))
+		for i, sub := range e.Subs {
+			derivatives[i] = t.derive(sub, symbol)
+		}
+		return t.mkSel(derivatives...)
+	case OpSeq:
+		head, rest := e.Subs[0], t.mkSeq(e.Subs[1:]...)
+		d := t.mkSeq(t.derive(head, symbol), rest)
+		if t.nullable[head] {
+			d = t.mkSel(d, t.derive(rest, symbol))
+		}
+		return d
+	}
+	return nothing
+}
+
+//first collects the symbols that can start a sequence of the normalized expression.
+// Normalized expressions other than nothing always match something, so it's exactly the symbols with a non empty derivative.
+func (t *exprTable) first(e *Expr, symbols map[string]interface{}) {
+	switch e.Op {
+	case OpSymbol:
+		symbols[e.Name] = nil
+	case OpStar:
+		t.first(e.Subs[0], symbols)
+	case OpSel:
+		for _, sub := range e.Subs {
+			t.first(sub, symbols)
+		}
+	case OpSeq:
+		for _, sub := range e.Subs {
+			t.first(sub, symbols)
+			if !t.nullable[sub] {
+				return
+			}
+		}
+	}
+}
+
+//derivState is a memoized derivative
+type derivState struct {
+	expr     *Expr
+	nullable bool
+	expected []string               // sorted symbols with a derivative, nil until computed
+	next     map[string]*derivState // derivatives computed so far, nil when it's nothing
+}
+
+//Derivatives matches sequences on an expression by computing its derivatives on demand.
+// States are memoized, and shared by every matcher. It is not safe for concurrent use.
+type Derivatives struct {
+	table  *exprTable
+	start  *derivState
+	states map[*Expr]*derivState // every state computed so far, by interned expression
+}
+
+//NewDerivatives prepares the expression for derivative based matching. Nothing is computed yet.
+func NewDerivatives(e *Expr) *Derivatives {
+	d := &Derivatives{table: newExprTable(), states: make(map[*Expr]*derivState)}
+	d.start = d.state(d.table.normalize(e))
+	return d
+}
+
+//state returns the memoized state of an interned expression
+func (d *Derivatives) state(e *Expr) *derivState {
+	if s, ok := d.states[e]; ok {
+		return s
+	}
+	s := &derivState{expr: e, nullable: d.table.nullable[e], next: make(map[string]*derivState)}
+	d.states[e] = s
+	return s
+}
+
+//derive returns the state after reading the symbol, or nil if the symbol is not expected
+func (d *Derivatives) derive(s *derivState, symbol string) *derivState {
+	if n, ok := s.next[symbol]; ok {
+		return n
+	}
+	var n *derivState
+	if e := d.table.derive(s.expr, symbol); e.Op != OpNothing {
+		n = d.state(e)
+	}
+	s.next[symbol] = n
+	return n
+}
+
+//States returns the number of distinct derivatives computed so far.
+func (d *Derivatives) States() int {
+	return len(d.states)
+}
+
+//Match tells if the whole sequence of symbols is accepted by the expression.
+func (d *Derivatives) Match(symbols []string) bool {
+	m := d.NewMatcher()
+	for _, s := range symbols {
+		if !m.Next(s) {
+			return false
+		}
+	}
+	return m.Accepts()
+}
+
+//DerivativeMatcher reads a sequence of symbols one at a time, with the same semantic as Matcher.
+type DerivativeMatcher struct {
+	derivatives *Derivatives
+	current     *derivState
+}
+
+//NewMatcher creates a matcher at the start of the expression.
+func (d *Derivatives) NewMatcher() *DerivativeMatcher {
+	return &DerivativeMatcher{derivatives: d, current: d.start}
+}
+
+//Reset goes back to the start of the expression.
+func (m *DerivativeMatcher) Reset() {
+	m.current = m.derivatives.start
+}
+
+//Next reads a symbol, and returns false if it is not expected. In that case the symbol is ignored.
+func (m *DerivativeMatcher) Next(symbol string) bool {
+	n := m.derivatives.derive(m.current, symbol)
+	if n == nil {
+		return false
+	}
+	m.current = n
+	return true
+}
+
+//Accepts tells if the symbols read so far are a complete sequence.
+func (m *DerivativeMatcher) Accepts() bool {
+	return m.current.nullable
+}
+
+//Expected returns the sorted list of symbols that can be read next.
+func (m *DerivativeMatcher) Expected() []string {
+	s := m.current
+	if s.expected == nil {
+		symbols := make(map[string]interface{})
+		m.derivatives.table.first(s.expr, symbols)
+		s.expected = make([]string, 0, len(symbols))
+		for name := range symbols {
+			s.expected = append(s.expected, name)
+		}
+		sort.Strings(s.expected)
+	}
+	return append(make([]string, 0, len(s.expected)), s.expected...)
+}
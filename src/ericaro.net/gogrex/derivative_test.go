@@ -0,0 +1,64 @@
+package gogrex
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDerivatives(t *testing.T) {
+	expressions := append([]string{"a|b", "(a,b)|(a,c)", "(a|b)*,c", "a?,b?,c?", "(a*)*", "(a+|b)+"}, exps[3:]...)
+	symbols := []string{"a", "b", "c", "x", "end"}
+	for _, exp := range expressions {
+		var m StringManager
+		g, err := ParseGrex(&m, exp)
+		if err != nil {
+			t.Fatalf("%s: %v", exp, err)
+		}
+		e, err := ParseExpr(exp)
+		if err != nil {
+			t.Fatalf("%s: %v", exp, err)
+		}
+		d := NewDerivatives(e)
+		for _, s := range sequences(symbols, 4) {
+			var gm, dm SequenceMatcher = g.NewMatcher(), d.NewMatcher()
+			for _, sym := range s {
+				if !reflect.DeepEqual(gm.Expected(), dm.Expected()) {
+					t.Fatalf("%s on %v: expected %v, derivatives expect %v", exp, s, gm.Expected(), dm.Expected())
+				}
+				if gm.Next(sym) != dm.Next(sym) {
+					t.Fatalf("%s on %v: derivatives disagree on %s", exp, s, sym)
+				}
+			}
+			if gm.Accepts() != dm.Accepts() {
+				t.Errorf("%s on %v: derivatives disagree on acceptance", exp, s)
+			}
+		}
+		if d.States() > 20 {
+			t.Errorf("%s: too many states %d", exp, d.States())
+		}
+	}
+}
+
+func TestDerivativesLargeAlternation(t *testing.T) {
+	// every alternative is built, compared and sorted once
+	var b strings.Builder
+	for i := 0; i < 5000; i++ {
+		if i > 0 {
+			b.WriteString(" | ")
+		}
+		fmt.Fprintf(&b, "(s%d, x%d*)", i, i%10)
+	}
+	e, err := ParseExpr(b.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDerivatives(e)
+	if !d.Match([]string{"s4321", "x1", "x1"}) || d.Match([]string{"s4321", "x2"}) {
+		t.Errorf("unexpected match on a large alternation")
+	}
+	if len(d.NewMatcher().Expected()) != 5000 {
+		t.Errorf("expected 5000 symbols, got %d", len(d.NewMatcher().Expected()))
+	}
+}
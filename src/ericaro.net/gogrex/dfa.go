@@ -0,0 +1,249 @@
+package gogrex
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// A grex can have several outbounds with the same name. Determinizing it is the classic subset construction: every
+// state of the result is a set of vertices of the grex, and there is at most one transition per symbol.
+
+//dfa is a determinized grex, stored as tables. The start state is 0.
+type dfa struct {
+	symbols []string // the sorted alphabet
+	next    [][]int  // next[state][symbol] is the next state, -1 when there is none
+	accept  []bool   // accepting states
+}
+
+//determinize runs the subset construction over the grex.
+func (g *Grex) determinize() *dfa {
+	d, _ := g.determinizeLimited(nil) // nothing can fail without limits
+	return d
+}
+
+//determinizeLimited runs the subset construction, and checks the number of states and transitions as they are found.
+func (g *Grex) determinizeLimited(l *limiter) (*dfa, error) {
+	vertices := g.graph.sortedVertices()
+	index := make(map[Vertex]int)
+	for i, v := range vertices {
+		index[v] = i
+	}
+	alphabet := make(map[string]int)
+	for t := range g.graph.edges {
+		alphabet[t.Name()] = 0
+	}
+	d := &dfa{}
+	for name := range alphabet {
+		d.symbols = append(d.symbols, name)
+	}
+	sort.Strings(d.symbols)
+	for i, name := range d.symbols {
+		alphabet[name] = i
+	}
+
+	states := make(map[string]int) // a set of vertices, by key, to its state
+	var sets [][]int               // the set of vertices of each state
+	add := func(set []int) int {
+		sort.Ints(set)
+		key := fmt.Sprint(set)
+		if s, ok := states[key]; ok {
+			return s
+		}
+		s := len(sets)
+		states[key] = s
+		sets = append(sets, set)
+		accept := false
+		for _, v := range set {
+			if _, ok := g.outs[vertices[v]]; ok {
+				accept = true
+			}
+		}
+		d.accept = append(d.accept, accept)
+		d.next = append(d.next, nil)
+		return s
+	}
+	add([]int{index[g.in]})
+	transitions := 0
+	for s := 0; s < len(sets); s++ { // sets grows while new states are found
+		if err := l.check(len(sets), transitions); err != nil {
+			return nil, err
+		}
+		targets := make([]map[int]bool, len(d.symbols))
+		for _, v := range sets[s] {
+			for _, t := range g.graph.OutEdges(vertices[v]) {
+				a := alphabet[t.Name()]
+				if targets[a] == nil {
+					targets[a] = make(map[int]bool)
+				}
+				targets[a][index[g.graph.Dest(t)]] = true
+			}
+		}
+		next := make([]int, len(d.symbols))
+		for a, target := range targets {
+			next[a] = -1
+			if target == nil {
+				continue
+			}
+			set := make([]int, 0, len(target))
+			for v := range target {
+				set = append(set, v)
+			}
+			next[a] = add(set)
+			transitions++
+		}
+		d.next[s] = next
+	}
+	return d, l.check(len(sets), transitions)
+}
+
+//live returns the states that are both reachable from the start, and can reach an accepting state.
+func (d *dfa) live() []bool {
+	// states are numbered in discovery order: every state is reachable.
+	live := make([]bool, len(d.accept))
+	copy(live, d.accept)
+	for changed := true; changed; {
+		changed = false
+		for s, next := range d.next {
+			if live[s] {
+				continue
+			}
+			for _, n := range next {
+				if n >= 0 && live[n] {
+					live[s], changed = true, true
+					break
+				}
+			}
+		}
+	}
+	return live
+}
+
+//Determinize returns an equivalent grex where every vertex has at most one outbound per symbol, using the same Manager.
+func (g *Grex) Determinize() *Grex {
+	return g.determinize().grex(g.manager)
+}
+
+//DeterminizeContext is Determinize within the limits: MaxVertices bounds the states, and MaxEdges the transitions.
+// It stops as soon as the context is done, and returns its error.
+func (g *Grex) DeterminizeContext(ctx context.Context, limits Limits) (*Grex, error) {
+	d, err := g.determinizeLimited(&limiter{ctx: ctx, limits: limits})
+	if err != nil {
+		return nil, err
+	}
+	return d.grex(g.manager), nil
+}
+
+//grex builds the graph of the dfa, using the Manager
+func (d *dfa) grex(m Manager) *Grex {
+	g := NewGrex(m)
+	vertices := make([]Vertex, len(d.next))
+	for s := range vertices {
+		vertices[s] = m.NewVertex()
+		g.graph.AddVertex(vertices[s])
+		if d.accept[s] {
+			g.outs[vertices[s]] = nil
+		}
+	}
+	for s, next := range d.next {
+		for a, n := range next {
+			if n >= 0 {
+				g.graph.AddEdge(m.NewEdge(d.symbols[a]), vertices[s], vertices[n])
+			}
+		}
+	}
+	g.in = vertices[0]
+	return g
+}
+
+//Minimize returns the smallest deterministic grex that accepts the same sequences, using the same Manager.
+func (g *Grex) Minimize() *Grex {
+	return g.determinize().minimize().grex(g.manager)
+}
+
+//minimize removes the dead states, and merges the equivalent ones by partition refinement (Moore's algorithm).
+func (d *dfa) minimize() *dfa {
+	live := d.live()
+	if !live[0] { // nothing is accepted: a single state
+		next := make([]int, len(d.symbols))
+		for a := range next {
+			next[a] = -1
+		}
+		return &dfa{symbols: d.symbols, next: [][]int{next}, accept: []bool{false}}
+	}
+	// class of every state, -1 for dead ones. At first, accepting states are apart from the others.
+	class := make([]int, len(d.next))
+	classes := 0
+	for s := range class {
+		switch {
+		case !live[s]:
+			class[s] = -1
+		case d.accept[s]:
+			class[s] = 1
+		}
+	}
+	for _, c := range []int{0, 1} {
+		for s := range class {
+			if class[s] == c {
+				classes++
+				break
+			}
+		}
+	}
+	for {
+		// states stay together if their successors are in the same classes
+		signatures := make(map[string]int)
+		refined := make([]int, len(class))
+		for s := range class {
+			if class[s] < 0 {
+				refined[s] = -1
+				continue
+			}
+			signature := []int{class[s]}
+			for _, t := range d.next[s] {
+				if t < 0 {
+					signature = append(signature, -1)
+				} else {
+					signature = append(signature, class[t])
+				}
+			}
+			key := fmt.Sprint(signature)
+			c, ok := signatures[key]
+			if !ok {
+				c = len(signatures)
+				signatures[key] = c
+			}
+			refined[s] = c
+		}
+		class = refined
+		if len(signatures) == classes {
+			break
+		}
+		classes = len(signatures)
+	}
+
+	// number the classes in discovery order from the start
+	state := map[int]int{class[0]: 0}
+	representatives := []int{0}
+	m := &dfa{symbols: d.symbols}
+	for i := 0; i < len(representatives); i++ { // representatives grows while new classes are found
+		s := representatives[i]
+		next := make([]int, len(d.symbols))
+		for a, t := range d.next[s] {
+			next[a] = -1
+			if t < 0 || class[t] < 0 {
+				continue
+			}
+			n, ok := state[class[t]]
+			if !ok {
+				n = len(representatives)
+				state[class[t]] = n
+				representatives = append(representatives, t)
+			}
+			next[a] = n
+		}
+		m.next = append(m.next, next)
+		m.accept = append(m.accept, d.accept[s])
+	}
+	return m
+}
@@ -0,0 +1,34 @@
+package gogrex
+
+import (
+	"testing"
+)
+
+func TestMinimize(t *testing.T) {
+	cases := []struct {
+		exp      string
+		vertices int
+	}{
+		{"a", 2},
+		{"(a,b)|(a,c)", 3},
+		{"(a|b)*, (a|b)*", 1},
+		{"(a,b)*|(a,b)+", 2},
+		{"a*, a", 2},
+	}
+	for _, c := range cases {
+		var m StringManager
+		g, err := ParseGrex(&m, c.exp)
+		if err != nil {
+			t.Fatalf("%s: %v", c.exp, err)
+		}
+		min := g.Minimize()
+		if got := len(min.Vertices()); got != c.vertices {
+			t.Errorf("%s: expected %d vertices, got %d\n%s", c.exp, c.vertices, got, min)
+		}
+		for _, s := range sequences([]string{"a", "b", "c"}, 4) {
+			if g.Match(s) != min.Match(s) {
+				t.Errorf("%s on %v: the minimized grex is not equivalent", c.exp, s)
+			}
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package gogrex
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// The dot output is driven by DotOptions. Without options, it is the historical output of String: a small left to
+// right graph, vertices are points, except the input and outputs that are boxes labelled In, Out (or IO if both).
+
+//DotOptions configures the dot output. The zero value, like a nil *DotOptions, is the default output.
+type DotOptions struct {
+	RankDir           string            // rank direction, "LR" when empty
+	Size              string            // drawing size in inches, "6,4" when empty
+	VertexLabels      bool              // label vertices with their value (the id given by the Manager), instead of points
+	EdgeColors        map[string]string // edge colors, by symbol name
+	HighlightEdges    []Edge            // edges to highlight, a path for instance
+	HighlightVertices []Vertex          // vertices to highlight, the active vertices of a matcher for instance
+	HighlightColor    string            // highlight color, "red" when empty
+	Clusters          []DotCluster      // vertices to group together
+}
+
+//DotCluster is a group of vertices drawn in a box. Clusters can be nested, a vertex must appear in one cluster only.
+type DotCluster struct {
+	Label    string
+	Vertices []Vertex
+	Clusters []DotCluster
+}
+
+//dotID returns the vertex as a dot identifier, quoted if needed
+func dotID(v Vertex) string {
+	s := fmt.Sprint(v)
+	for i, r := range s {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' && (i > 0 || isNumber(s))) {
+			return fmt.Sprintf("%q", s)
+		}
+	}
+	if s == "" {
+		return `""`
+	}
+	return s
+}
+
+func isNumber(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
+
+//WriteDot writes the graph in dot format. 'in' and 'outs' are drawn as boxes.
+func (g *DirectedSparseMultigraph) WriteDot(w io.Writer, in Vertex, outs map[Vertex]interface{}, opts *DotOptions) error {
+	if opts == nil {
+		opts = &DotOptions{}
+	}
+	rankdir, size, highlight := opts.RankDir, opts.Size, opts.HighlightColor
+	if rankdir == "" {
+		rankdir = "LR"
+	}
+	if size == "" {
+		size = "6,4"
+	}
+	if highlight == "" {
+		highlight = "red"
+	}
+	node := `label="",shape=point,style=filled`
+	if opts.VertexLabels {
+		node = `shape=circle`
+	}
+	e := &errWriter{w: w}
+	e.printf("digraph { size=%q;rankdir=%s; ratio = fill; node [%s];\n\t", size, rankdir, node)
+
+	highlighted := make(map[Vertex]bool)
+	for _, v := range opts.HighlightVertices {
+		highlighted[v] = true
+	}
+	// attributes of every vertex that is not a plain point
+	attributes := func(v Vertex) []string {
+		var attrs []string
+		_, out := outs[v]
+		kind := ""
+		switch {
+		case v == in && out:
+			kind = "IO"
+		case v == in:
+			kind = "In"
+		case out:
+			kind = "Out"
+		}
+		if kind != "" {
+			label := kind
+			if opts.VertexLabels {
+				label = fmt.Sprintf("%s %v", kind, v)
+			}
+			attrs = append(attrs, fmt.Sprintf("label=%q", label), "shape=box")
+		}
+		if highlighted[v] {
+			attrs = append(attrs, fmt.Sprintf("color=%q", highlight), fmt.Sprintf("fillcolor=%q", highlight), "style=filled")
+		}
+		return attrs
+	}
+	writeVertex := func(v Vertex) {
+		if attrs := attributes(v); len(attrs) > 0 {
+			e.printf("%s [%s];\n\t", dotID(v), strings.Join(attrs, ","))
+		} else if opts.VertexLabels || len(opts.Clusters) > 0 {
+			e.printf("%s;\n\t", dotID(v))
+		}
+	}
+
+	// clustered vertices first, inside their subgraphs
+	clustered := make(map[Vertex]bool)
+	count := 0
+	var writeCluster func(c DotCluster)
+	writeCluster = func(c DotCluster) {
+		e.printf("subgraph cluster_%d { label=%q;\n\t", count, c.Label)
+		count++
+		for _, v := range c.Vertices {
+			clustered[v] = true
+			writeVertex(v)
+		}
+		for _, sub := range c.Clusters {
+			writeCluster(sub)
+		}
+		e.printf("}\n\t")
+	}
+	for _, c := range opts.Clusters {
+		writeCluster(c)
+	}
+	for _, v := range g.sortedVertices() {
+		if !clustered[v] {
+			writeVertex(v)
+		}
+	}
+
+	highlightedEdges := make(map[Edge]bool)
+	for _, t := range opts.HighlightEdges {
+		highlightedEdges[t] = true
+	}
+	_, edges := g.indexed()
+	for _, ie := range edges {
+		t := ie.edge
+		b := g.edges[t]
+		attrs := []string{fmt.Sprintf("label=%q", t.Name())}
+		if color, ok := opts.EdgeColors[t.Name()]; ok {
+			attrs = append(attrs, fmt.Sprintf("color=%q", color), fmt.Sprintf("fontcolor=%q", color))
+		}
+		if highlightedEdges[t] {
+			attrs = append(attrs, fmt.Sprintf("color=%q", highlight), "penwidth=2")
+		}
+		e.printf("%s -> %s [%s];\n\t", dotID(b.start), dotID(b.end), strings.Join(attrs, ","))
+	}
+	e.printf("}")
+	return e.err
+}
+
+//WriteDot writes the grex graph in dot format.
+func (g *Grex) WriteDot(w io.Writer, opts *DotOptions) error {
+	return g.graph.WriteDot(w, g.in, g.outs, opts)
+}
+
+//SubexpressionClusters groups the vertices by the sub expression they belong to: every operand of "*", "+" or "?"
+// that is not a single symbol is a cluster. A vertex belongs to the smallest sub expression that contains all
+// the edges around it. The expression must be the one the grex was parsed from, as it relies on edge spans.
+func (g *Grex) SubexpressionClusters(e *Expr) []DotCluster {
+	type group struct {
+		expr     *Expr
+		vertices []Vertex
+		subs     []*group
+	}
+	root := &group{}
+	// build the tree of groups
+	var collect func(e *Expr, parent *group)
+	collect = func(e *Expr, parent *group) {
+		for _, sub := range e.Subs {
+			p := parent
+			if (e.Op == OpStar || e.Op == OpPlus || e.Op == OpOpt) && sub.Op != OpSymbol {
+				p = &group{expr: e}
+				parent.subs = append(parent.subs, p)
+			}
+			collect(sub, p)
+		}
+	}
+	collect(e, root)
+
+	contains := func(e *Expr, s Span) bool { return e.Span.Start <= s.Start && s.End <= e.Span.End }
+	for _, v := range g.graph.sortedVertices() {
+		var spans []Span
+		for t, b := range g.graph.edges {
+			if b.start == v || b.end == v {
+				if s, ok := g.spans[t]; ok {
+					spans = append(spans, s)
+				}
+			}
+		}
+		if len(spans) == 0 {
+			continue
+		}
+		// walk down the tree while a sub group contains every span
+		current := root
+	descend:
+		for {
+			for _, sub := range current.subs {
+				all := true
+				for _, s := range spans {
+					all = all && contains(sub.expr, s)
+				}
+				if all {
+					current = sub
+					continue descend
+				}
+			}
+			break
+		}
+		current.vertices = append(current.vertices, v)
+	}
+
+	var clusters func(g *group) []DotCluster
+	clusters = func(g *group) (result []DotCluster) {
+		for _, sub := range g.subs {
+			c := DotCluster{Label: sub.expr.String(), Vertices: sub.vertices, Clusters: clusters(sub)}
+			if len(c.Vertices) > 0 || len(c.Clusters) > 0 {
+				result = append(result, c)
+			}
+		}
+		sort.SliceStable(result, func(i, j int) bool { return result[i].Label < result[j].Label })
+		return
+	}
+	return clusters(root)
+}
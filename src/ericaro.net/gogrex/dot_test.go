@@ -0,0 +1,61 @@
+package gogrex
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteDot(t *testing.T) {
+	var m StringManager
+	g, err := ParseGrex(&m, "a, (b|c)*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.String() != g.String() {
+		t.Errorf("default dot output is not stable")
+	}
+	if !strings.HasPrefix(g.String(), `digraph { size="6,4";rankdir=LR; ratio = fill; node [label="",shape=point,style=filled];`) {
+		t.Errorf("unexpected default header\n%s", g.String())
+	}
+
+	out, _ := g.sortedOutEdges()
+	path := g.ShortestPath(g.in, g.graph.Dest(out[g.in][0]))
+	var b bytes.Buffer
+	err = g.WriteDot(&b, &DotOptions{
+		RankDir:           "TB",
+		VertexLabels:      true,
+		EdgeColors:        map[string]string{"b": "blue"},
+		HighlightEdges:    path,
+		HighlightVertices: []Vertex{g.in},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dot := b.String()
+	for _, want := range []string{"rankdir=TB", "shape=circle", `label="b",color="blue"`, `label="a",color="red",penwidth=2`, `fillcolor="red"`} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("missing %s in\n%s", want, dot)
+		}
+	}
+}
+
+func TestSubexpressionClusters(t *testing.T) {
+	var m StringManager
+	e, err := ParseExpr("a, (b, c)*, d?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := e.Grex(&m)
+	clusters := g.SubexpressionClusters(e)
+	if len(clusters) != 1 || clusters[0].Label != "(b, c)*" || len(clusters[0].Vertices) != 1 {
+		t.Fatalf("unexpected clusters %v", clusters)
+	}
+	var b bytes.Buffer
+	if err := g.WriteDot(&b, &DotOptions{Clusters: clusters}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `subgraph cluster_0 { label="(b, c)*";`) {
+		t.Errorf("missing cluster in\n%s", b.String())
+	}
+}
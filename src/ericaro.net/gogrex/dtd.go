@@ -0,0 +1,301 @@
+package gogrex
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DTD element declarations  <!ELEMENT x (a, b?, (c|d)*)> have content models that are almost gogrex expressions.
+// The content model is scanned into the very same items as the lexer (itemLeft, itemSeq, itemStar ...), so that the
+// grex is built by the shunting yard exactly like any expression.
+//
+// Character data is not part of the graph: a grex only describes the sequence of child elements, so
+// "(#PCDATA)" is empty and "(#PCDATA|a|b)*" is "(a|b)*".
+// Other declarations (ATTLIST, ENTITY, NOTATION), comments and processing instructions are skipped.
+
+//ReadDTD reads every ELEMENT declaration of a DTD, and build a Grex of its content model, using the Manager.
+// The returned map is indexed by element name. Spans refer to byte offsets in the DTD.
+func ReadDTD(m Manager, r io.Reader) (map[string]*Grex, error) {
+	input, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	d := &dtdScanner{input: string(input), manager: m, elements: make(map[string]*Grex)}
+	if err := d.run(); err != nil {
+		return nil, err
+	}
+	return d.elements, nil
+}
+
+//dtdScanner reads a whole DTD
+type dtdScanner struct {
+	input    string
+	pos      int // current position in the input
+	manager  Manager
+	elements map[string]*Grex
+	any      map[string]Span // elements declared as ANY, resolved once every element is known
+}
+
+func (d *dtdScanner) run() error {
+	for {
+		d.skipSpace()
+		switch {
+		case d.pos >= len(d.input):
+			return d.resolveAny()
+		case d.hasPrefix("<!--"):
+			if err := d.skipUntil("<!--", "-->"); err != nil {
+				return err
+			}
+		case d.hasPrefix("<?"):
+			if err := d.skipUntil("<?", "?>"); err != nil {
+				return err
+			}
+		case d.hasPrefix("<!ELEMENT") && d.pos+9 < len(d.input) && unicode.IsSpace(rune(d.input[d.pos+9])):
+			d.pos += len("<!ELEMENT")
+			if err := d.element(); err != nil {
+				return err
+			}
+		case d.hasPrefix("<!"):
+			if err := d.skipDeclaration(); err != nil {
+				return err
+			}
+		case d.hasPrefix("%"): // parameter entity reference between declarations
+			if err := d.skipUntil("%", ";"); err != nil {
+				return err
+			}
+		default:
+			return d.errorf("unexpected %q", d.input[d.pos])
+		}
+	}
+}
+
+//element parses the remaining of an ELEMENT declaration: name, content spec, and the closing '>'
+func (d *dtdScanner) element() error {
+	d.skipSpace()
+	name, _ := d.name()
+	if name == "" {
+		return d.errorf("missing element name")
+	}
+	if _, exists := d.elements[name]; exists {
+		return d.errorf("element %s declared twice", name)
+	}
+	d.skipSpace()
+	var err error
+	switch {
+	case d.hasPrefix("EMPTY"):
+		d.pos += len("EMPTY")
+		d.elements[name] = empty(d.manager)
+	case d.hasPrefix("ANY"):
+		if d.any == nil {
+			d.any = make(map[string]Span)
+		}
+		d.any[name] = Span{d.pos, d.pos + len("ANY")}
+		d.pos += len("ANY")
+		d.elements[name] = nil // placeholder, so that it's declared
+	case d.hasPrefix("("):
+		start := d.pos
+		d.pos++
+		d.skipSpace()
+		if d.hasPrefix("#PCDATA") {
+			d.elements[name], err = d.mixed()
+		} else {
+			d.pos = start
+			d.elements[name], err = d.children()
+		}
+	default:
+		return d.errorf("invalid content specification for element %s", name)
+	}
+	if err != nil {
+		return err
+	}
+	d.skipSpace()
+	if !d.hasPrefix(">") {
+		return d.errorf("missing '>' at the end of element %s", name)
+	}
+	d.pos++
+	return nil
+}
+
+//mixed parses a mixed content model: "(#PCDATA)" or "(#PCDATA|a|b)*", the leading "(" being already read.
+func (d *dtdScanner) mixed() (*Grex, error) {
+	d.pos += len("#PCDATA")
+	var g *Grex
+	for {
+		d.skipSpace()
+		switch {
+		case d.hasPrefix(")*"):
+			d.pos += 2
+			if g == nil {
+				return empty(d.manager), nil
+			}
+			return star(g), nil
+		case d.hasPrefix(")"):
+			d.pos++
+			if g != nil {
+				return nil, d.errorf("mixed content with elements must end with ')*'")
+			}
+			return empty(d.manager), nil
+		case d.hasPrefix("|"):
+			d.pos++
+			d.skipSpace()
+			name, span := d.name()
+			if name == "" {
+				return nil, d.errorf("missing element name in mixed content")
+			}
+			t := terminal(d.manager, name, span)
+			if g == nil {
+				g = t
+			} else {
+				g = sel(g, t)
+			}
+		default:
+			return nil, d.errorf("invalid mixed content")
+		}
+	}
+}
+
+//children scans a children content model into items, and builds the grex from them.
+func (d *dtdScanner) children() (*Grex, error) {
+	var items []item
+	depth := 0
+	for {
+		d.skipSpace()
+		if d.pos >= len(d.input) {
+			return nil, d.errorf("unterminated content model")
+		}
+		it := item{pos: d.pos}
+		switch c := d.input[d.pos]; c {
+		case '(':
+			it.typ = itemLeft
+			depth++
+		case ')':
+			it.typ = itemRight
+			depth--
+		case ',':
+			it.typ = itemSeq
+		case '|':
+			it.typ = itemSel
+		case '?':
+			it.typ = itemOpt
+		case '*':
+			it.typ = itemStar
+		case '+':
+			it.typ = itemPlus
+		case '%':
+			return nil, d.errorf("parameter entity references are not supported in content models")
+		default:
+			name, _ := d.name()
+			if name == "" {
+				return nil, d.errorf("unexpected %q in content model", c)
+			}
+			items = append(items, item{itemIdentifier, name, it.pos})
+			continue
+		}
+		d.pos++
+		it.val = d.input[it.pos:d.pos]
+		items = append(items, it)
+		if depth == 0 {
+			break
+		}
+	}
+	// the whole group can be followed by a single occurrence indicator
+	if d.pos < len(d.input) && strings.ContainsRune("?*+", rune(d.input[d.pos])) {
+		typ := map[byte]itemType{'?': itemOpt, '*': itemStar, '+': itemPlus}[d.input[d.pos]]
+		items = append(items, item{typ, d.input[d.pos : d.pos+1], d.pos})
+		d.pos++
+	}
+
+	tokens := make(tokenSlice, len(items)) // everything is already scanned
+	for i, it := range items {
+		tokens[i] = it
+	}
+	g, err := build(d.manager, &tokens)
+	if err != nil {
+		return nil, d.errorf("%s", err)
+	}
+	return g, nil
+}
+
+//resolveAny builds the content of ANY elements: any sequence of declared elements.
+func (d *dtdScanner) resolveAny() error {
+	var names []string
+	for name := range d.elements {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for name, span := range d.any {
+		var g *Grex
+		for _, n := range names {
+			t := terminal(d.manager, n, span)
+			if g == nil {
+				g = t
+			} else {
+				g = sel(g, t)
+			}
+		}
+		d.elements[name] = star(g)
+	}
+	return nil
+}
+
+//name reads an XML name at the current position. It returns "" if there is none.
+func (d *dtdScanner) name() (string, Span) {
+	start := d.pos
+	for _, r := range d.input[d.pos:] {
+		if !(unicode.IsLetter(r) || r == '_' || r == ':' || d.pos > start && (unicode.IsDigit(r) || r == '-' || r == '.')) {
+			break
+		}
+		d.pos += len(string(r))
+	}
+	return d.input[start:d.pos], Span{start, d.pos}
+}
+
+func (d *dtdScanner) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(d.input[d.pos:], prefix)
+}
+
+func (d *dtdScanner) skipSpace() {
+	for d.pos < len(d.input) && unicode.IsSpace(rune(d.input[d.pos])) {
+		d.pos++
+	}
+}
+
+//skipUntil skips the opening string, and everything up to the closing one.
+func (d *dtdScanner) skipUntil(open, close string) error {
+	i := strings.Index(d.input[d.pos+len(open):], close)
+	if i < 0 {
+		return d.errorf("missing %q", close)
+	}
+	d.pos += len(open) + i + len(close)
+	return nil
+}
+
+//skipDeclaration skips any "<!...>" declaration, '>' inside quoted strings do not count.
+func (d *dtdScanner) skipDeclaration() error {
+	var quote byte
+	for d.pos += 2; d.pos < len(d.input); d.pos++ {
+		switch c := d.input[d.pos]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			d.pos++
+			return nil
+		}
+	}
+	return d.errorf("unterminated declaration")
+}
+
+//errorf returns an error prefixed by the current line number.
+func (d *dtdScanner) errorf(format string, args ...interface{}) error {
+	line := strings.Count(d.input[:d.pos], "\n") + 1
+	return errors.New(fmt.Sprintf("dtd:%d: %s", line, fmt.Sprintf(format, args...)))
+}
@@ -0,0 +1,82 @@
+package gogrex
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+const testDTD = `<?xml version="1.0" encoding="UTF-8"?>
+<!-- a small address book -->
+<!ELEMENT book (person*)>
+<!ELEMENT person (name, alias?, (telephone|email)+)>
+<!ATTLIST person id ID #REQUIRED note CDATA "a > b">
+<!ELEMENT name (#PCDATA)>
+<!ELEMENT alias (#PCDATA|b|i)*>
+<!ELEMENT telephone EMPTY>
+<!ELEMENT email (#PCDATA)>
+<!ELEMENT b (#PCDATA)>
+<!ELEMENT i (#PCDATA)>
+<!ELEMENT extra ANY>
+`
+
+//inputNames returns the sorted names of the edges that leave the input vertex
+func inputNames(g *Grex) []string {
+	var names []string
+	for _, e := range g.OutputEdges(g.InputVertex()) {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestReadDTD(t *testing.T) {
+	var m StringManager
+	elements, err := ReadDTD(&m, strings.NewReader(testDTD))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(elements) != 9 {
+		t.Fatalf("expected 9 elements, got %d", len(elements))
+	}
+	goldens := map[string]string{
+		"book":      "person",
+		"person":    "name",
+		"name":      "",
+		"alias":     "b i",
+		"telephone": "",
+		"extra":     "alias b book email extra i name person telephone",
+	}
+	for name, golden := range goldens {
+		if got := strings.Join(inputNames(elements[name]), " "); got != golden {
+			t.Errorf("%s: expected %q got %q", name, golden, got)
+		}
+	}
+	// empty content accepts the empty sequence
+	for _, name := range []string{"book", "name", "alias", "telephone"} {
+		g := elements[name]
+		if _, ok := g.outs[g.in]; !ok {
+			t.Errorf("%s should accept an empty content", name)
+		}
+	}
+	if a := elements["person"].Ambiguities(); len(a) != 0 {
+		t.Errorf("unexpected ambiguities %v", a)
+	}
+}
+
+func TestReadDTDErrors(t *testing.T) {
+	invalids := []string{
+		"<!ELEMENT a (b,c>",
+		"<!ELEMENT a (#PCDATA|b)>",
+		"<!ELEMENT a EMPTY>\n<!ELEMENT a ANY>",
+		"<!ELEMENT a (%b;)>",
+		"<!ELEMENT a WHATEVER>",
+		"<!-- unterminated",
+	}
+	for _, dtd := range invalids {
+		var m StringManager
+		if _, err := ReadDTD(&m, strings.NewReader(dtd)); err == nil {
+			t.Errorf("%q: expected an error", dtd)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package gogrex
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The shunting yard output, in RPN, is interpreted into an abstract syntax tree. The tree is then either turned into
+// a Grex, or used directly (derivatives, printing).
+
+//Op is the operator of an expression node
+type Op int
+
+const (
+	OpNothing Op = iota // matches no sequence at all
+	OpEmpty             // matches the empty sequence
+	OpSymbol            // a single symbol
+	OpSeq               // "a, b"
+	OpSel               // "a | b"
+	OpStar              // "a*"
+	OpPlus              // "a+"
+	OpOpt               // "a?"
+)
+
+//Expr is a node of an expression syntax tree.
+type Expr struct {
+	Op   Op
+	Name string  // the symbol name, for OpSymbol
+	Subs []*Expr // the operands, one for unary operators, two or more for OpSeq and OpSel
+	Span Span    // the position in the source expression, from the first operand to the operator
+}
+
+//ParseError is a syntax error in an expression.
+type ParseError struct {
+	Pos int    // byte offset of the error in the expression
+	Msg string // what went wrong
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
+
+//exprStack is the RPN interpreter stack
+type exprStack []*Expr
+
+func (stack *exprStack) pop() (e *Expr, err error) {
+	if len(*stack) == 0 {
+		return nil, errors.New("Empty Stack")
+	}
+	e = (*stack)[len(*stack)-1]
+	*stack = (*stack)[:len(*stack)-1]
+	return
+}
+
+func (stack *exprStack) push(e *Expr) {
+	*stack = append(*stack, e)
+}
+
+//ParseExpr parses the expression into its syntax tree.
+func ParseExpr(expr string) (*Expr, error) {
+	return parse(lex(expr))
+}
+
+//parse reorders the tokens using the shunting yard, and interprets the output to build the syntax tree.
+// tokens can come from any source, as long as they are items.
+func parse(tokens tokenStream) (e *Expr, err error) {
+	grammar := shunting(tokens)
+
+	// now parses the expression in a RPN notation
+	var stack exprStack // as any RPN interpreter I need a stack
+	var t Token
+	for {
+		t, err = grammar.next()     //get a correct token, or an error
+		if t == nil && err == nil { // end detected
+			switch len(stack) {
+			case 0:
+				return nil, &ParseError{0, "empty expression"}
+			case 1:
+				return stack.pop() // return the last item in the stack
+			}
+			return nil, &ParseError{stack[1].Span.Start, "missing operator"}
+		}
+		if err != nil { // not the end, but an error though
+			return
+		}
+		i := t.(item)  // now I've got an item
+		switch i.typ { // operates,
+		case itemStar, itemPlus, itemOpt: // mono operand: pop, apply
+			this, err := stack.pop()
+			if err != nil {
+				return nil, &ParseError{i.pos, fmt.Sprintf("missing operand for %q", i.val)}
+			}
+			op := map[itemType]Op{itemStar: OpStar, itemPlus: OpPlus, itemOpt: OpOpt}[i.typ]
+			stack.push(&Expr{Op: op, Subs: []*Expr{this}, Span: Span{this.Span.Start, i.pos + len(i.val)}})
+		case itemSel, itemSeq: // binary operand: pop, pop, apply
+			b, err := stack.pop()
+			if err != nil {
+				return nil, &ParseError{i.pos, fmt.Sprintf("missing operand for %q", i.val)}
+			}
+			a, err := stack.pop()
+			if err != nil {
+				return nil, &ParseError{i.pos, fmt.Sprintf("missing operand for %q", i.val)}
+			}
+			op := OpSeq
+			if i.typ == itemSel {
+				op = OpSel
+			}
+			stack.push(&Expr{Op: op, Subs: []*Expr{a, b}, Span: Span{a.Span.Start, b.Span.End}})
+		case itemIdentifier: // leaf element
+			stack.push(&Expr{Op: OpSymbol, Name: i.val, Span: Span{i.pos, i.pos + len(i.val)}})
+		case itemError: // a lex error has occured
+			err = &ParseError{i.pos, i.val}
+			return
+		default: // unexpected token
+			err = &ParseError{i.pos, fmt.Sprintf("Invalid Token %s.", i.val)}
+			return
+		}
+	}
+}
+
+//Grex builds the graph of the expression, using the Manager.
+func (e *Expr) Grex(m Manager) *Grex {
+	switch e.Op {
+	case OpNothing:
+		g := NewGrex(m)
+		g.in = m.NewVertex()
+		g.graph.AddVertex(g.in)
+		return g
+	case OpEmpty:
+		return empty(m)
+	case OpSymbol:
+		return terminal(m, e.Name, e.Span)
+	case OpStar:
+		return star(e.Subs[0].Grex(m))
+	case OpPlus:
+		return plus(e.Subs[0].Grex(m))
+	case OpOpt:
+		return opt(e.Subs[0].Grex(m))
+	}
+	g := e.Subs[0].Grex(m)
+	for _, sub := range e.Subs[1:] {
+		if e.Op == OpSeq {
+			g = seq(g, sub.Grex(m))
+		} else {
+			g = sel(g, sub.Grex(m))
+		}
+	}
+	return g
+}
+
+//precedence of the operators when printed, same as the lexer items
+func (e *Expr) precedence() int {
+	switch e.Op {
+	case OpSeq:
+		return itemSeq.precedence
+	case OpSel:
+		return itemSel.precedence
+	case OpStar, OpPlus, OpOpt:
+		return itemStar.precedence
+	}
+	return 100 // leafs
+}
+
+//String prints the expression in its canonical form: single spaces after ",", around "|", and only the required parenthesis.
+// The empty expression is printed "()", and the expression that matches nothing "(|)", they cannot be parsed back.
+func (e *Expr) String() string {
+	var b strings.Builder
+	e.print(&b)
+	return b.String()
+}
+
+func (e *Expr) print(b *strings.Builder) {
+	switch e.Op {
+	case OpNothing:
+		b.WriteString("(|)")
+	case OpEmpty:
+		b.WriteString("()")
+	case OpSymbol:
+		b.WriteString(e.Name)
+	case OpStar, OpPlus, OpOpt:
+		e.printOperand(b, e.Subs[0])
+		b.WriteString(map[Op]string{OpStar: "*", OpPlus: "+", OpOpt: "?"}[e.Op])
+	case OpSeq, OpSel:
+		sep := ", "
+		if e.Op == OpSel {
+			sep = " | "
+		}
+		for i, sub := range e.Subs {
+			if i > 0 {
+				b.WriteString(sep)
+			}
+			e.printOperand(b, sub)
+		}
+	}
+}
+
+//printOperand prints a sub expression, between parenthesis if its operator binds less than e's.
+// "," and "|" are associative, so "(a, b), c" and "a, (b, c)" are both printed "a, b, c".
+func (e *Expr) printOperand(b *strings.Builder, sub *Expr) {
+	if p := sub.precedence(); p < e.precedence() || p == e.precedence() && p == itemStar.precedence {
+		b.WriteString("(")
+		sub.print(b)
+		b.WriteString(")")
+		return
+	}
+	sub.print(b)
+}
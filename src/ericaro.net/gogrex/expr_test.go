@@ -0,0 +1,74 @@
+package gogrex
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestExprString(t *testing.T) {
+	goldens := map[string]string{
+		"a":                                 "a",
+		"(a , b)|( c* )":                    "(a, b) | c*",
+		"a,(b,c)":                           "a, b, c",
+		"(a|b),c":                           "a | b, c",
+		"(a,b)*":                            "(a, b)*",
+		"((a)+)?":                           "(a+)?",
+		"(a, b+ /* toto */ )":               "a, b+",
+		"x|(y|z)":                           "x | y | z",
+		"name, alias?, (telephone, email)+": "name, alias?, (telephone, email)+",
+	}
+	for exp, golden := range goldens {
+		e, err := ParseExpr(exp)
+		if err != nil {
+			t.Fatalf("%s: %v", exp, err)
+		}
+		if got := e.String(); got != golden {
+			t.Errorf("%s: expected %q got %q", exp, golden, got)
+		}
+		// the canonical form is stable
+		if again, err := ParseExpr(golden); err != nil || again.String() != golden {
+			t.Errorf("%s: canonical form %q does not parse back to itself", exp, golden)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	positions := map[string]int{
+		"a)":          1,
+		"a, (b":       3,
+		"a b":         2,
+		"*, a":        0,
+		"a, b $":      5,
+		"a /* b":      2,
+		"a, // b\n c": -1, // valid
+	}
+	for exp, pos := range positions {
+		_, err := ParseExpr(exp)
+		if pos < 0 {
+			if err != nil {
+				t.Errorf("%q: unexpected error %v", exp, err)
+			}
+			continue
+		}
+		perr, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("%q: expected a ParseError, got %v", exp, err)
+		} else if perr.Pos != pos {
+			t.Errorf("%q: expected an error at %d, got %v", exp, pos, perr)
+		}
+	}
+}
+
+func TestParseLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+	var m StringManager
+	for i := 0; i < 100; i++ {
+		// errors stop the parsing early, valid expressions go to the end
+		for _, exp := range []string{"a), b, c", "(a, b", "a b c", "$ a, b", "", "a, b*"} {
+			ParseGrex(&m, exp)
+		}
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("parsing leaked %d goroutines", after-before)
+	}
+}
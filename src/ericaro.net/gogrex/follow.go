@@ -0,0 +1,76 @@
+package gogrex
+
+import (
+	"sort"
+)
+
+// First and follow symbols of sub expressions, as in the Glushkov construction. They tell which symbols can start a
+// sub expression, and which ones can come right after it in the whole expression.
+
+//First returns the sorted symbols that can start a sequence of the expression.
+func (e *Expr) First() []string {
+	symbols := make(map[string]interface{})
+	t := newExprTable()
+	t.first(t.normalize(e), symbols)
+	return sortedNames(symbols)
+}
+
+//Nullable tells if the expression accepts the empty sequence.
+func (e *Expr) Nullable() bool {
+	t := newExprTable()
+	return t.nullable[t.normalize(e)]
+}
+
+//path returns the nodes from e down to sub, nil if sub is not a node of e
+func (e *Expr) path(sub *Expr) []*Expr {
+	if e == sub {
+		return []*Expr{e}
+	}
+	for _, s := range e.Subs {
+		if p := s.path(sub); p != nil {
+			return append([]*Expr{e}, p...)
+		}
+	}
+	return nil
+}
+
+//Follow returns the sorted symbols that can come right after the sub expression, within e, and whether the sequence
+// can end there. sub must be a node of e, otherwise the result is nil and false.
+func (e *Expr) Follow(sub *Expr) (symbols []string, end bool) {
+	path := e.path(sub)
+	if path == nil {
+		return nil, false
+	}
+	names := make(map[string]interface{})
+	t := newExprTable()
+	// walk up the tree: what comes after a node is decided by its parent
+	for i := len(path) - 1; i > 0; i-- {
+		node, parent := path[i], path[i-1]
+		switch parent.Op {
+		case OpSeq:
+			k := 0
+			for parent.Subs[k] != node {
+				k++
+			}
+			for _, next := range parent.Subs[k+1:] {
+				t.first(t.normalize(next), names)
+				if !next.Nullable() {
+					return sortedNames(names), false
+				}
+			}
+		case OpStar, OpPlus: // the node can be repeated
+			t.first(t.normalize(node), names)
+		}
+	}
+	return sortedNames(names), true
+}
+
+//sortedNames returns the keys of the set, sorted
+func sortedNames(set map[string]interface{}) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
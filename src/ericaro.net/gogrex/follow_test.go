@@ -0,0 +1,45 @@
+package gogrex
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFirstAndFollow(t *testing.T) {
+	e, err := ParseExpr("a, (b, c?)*, d?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a, (b, c?)*, and d?
+	star, opt := e.Subs[0].Subs[1], e.Subs[1]
+	if len(e.Subs) != 2 || star.Op != OpStar || opt.Op != OpOpt {
+		t.Fatalf("unexpected tree %s", e)
+	}
+	cases := []struct {
+		sub           *Expr
+		first, follow string
+		end           bool
+	}{
+		{e, "[a]", "[]", true},
+		{e.Subs[0].Subs[0], "[a]", "[b d]", true},
+		{star, "[b]", "[d]", true},
+		{star.Subs[0], "[b]", "[b d]", true},
+		{star.Subs[0].Subs[0], "[b]", "[b c d]", true},
+		{opt, "[d]", "[]", true},
+	}
+	for _, c := range cases {
+		if got := fmt.Sprint(c.sub.First()); got != c.first {
+			t.Errorf("%s: expected first %s, got %s", c.sub, c.first, got)
+		}
+		follow, end := e.Follow(c.sub)
+		if got := fmt.Sprint(follow); got != c.follow || end != c.end {
+			t.Errorf("%s: expected follow %s %v, got %s %v", c.sub, c.follow, c.end, got, end)
+		}
+	}
+	if follow, end := e.Follow(&Expr{Op: OpSymbol, Name: "a"}); follow != nil || end {
+		t.Errorf("unexpected follow of a foreign node")
+	}
+	if !star.Nullable() || e.Nullable() {
+		t.Errorf("unexpected nullable")
+	}
+}
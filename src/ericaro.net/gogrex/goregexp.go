@@ -0,0 +1,207 @@
+package gogrex
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+// A grex is compiled into a Go regexp by turning every symbol into a single rune from the Unicode private use area.
+// A sequence of symbols is then encoded into a string of such runes, that the regexp matches.
+//
+// The pattern is computed by state elimination: vertices are removed one by one, and the edges around them are
+// replaced by edges labelled with the equivalent regular expression.
+
+// bounds of the Unicode Private Use Area
+const (
+	firstPrivateRune = '\uE000'
+	lastPrivateRune  = '\uF8FF'
+)
+
+//SymbolEncoder assigns a private use rune to every symbol name. Use the same encoder to compile a grex, and to encode its inputs.
+// The zero value is not usable, create it with make(SymbolEncoder).
+type SymbolEncoder map[string]rune
+
+//allocate gives a rune to every name that has none yet. Runes already in the encoder, even set by hand, are skipped.
+func (e SymbolEncoder) allocate(names []string) error {
+	used := make(map[rune]bool)
+	for name, r := range e {
+		if r < firstPrivateRune || r > lastPrivateRune || used[r] {
+			return errors.New(fmt.Sprintf("invalid rune %U for %s, runes must be distinct, in the private use area", r, name))
+		}
+		used[r] = true
+	}
+	next := rune(firstPrivateRune)
+	for _, name := range names {
+		if _, ok := e[name]; ok {
+			continue
+		}
+		for used[next] {
+			next++
+		}
+		if next > lastPrivateRune {
+			return errors.New(fmt.Sprintf("too many symbols, only %d are supported", lastPrivateRune-firstPrivateRune+1))
+		}
+		e[name] = next
+		used[next] = true
+	}
+	return nil
+}
+
+//Encode turns a sequence of symbols into the string matched by the compiled regexp.
+// Unknown symbols are encoded as utf8.RuneError, that no compiled regexp matches.
+func (e SymbolEncoder) Encode(symbols []string) string {
+	runes := make([]rune, len(symbols))
+	for i, s := range symbols {
+		r, ok := e[s]
+		if !ok {
+			r = utf8.RuneError
+		}
+		runes[i] = r
+	}
+	return string(runes)
+}
+
+//fragment is a piece of regexp. The empty string stands for the empty sequence.
+type fragment struct {
+	re     string
+	atomic bool // can be followed by a quantifier as is
+}
+
+func union(a, b fragment) fragment {
+	switch {
+	case a == b:
+		return a
+	case a.re == "":
+		return optional(b)
+	case b.re == "":
+		return optional(a)
+	}
+	return fragment{"(?:" + a.re + "|" + b.re + ")", true}
+}
+
+func optional(a fragment) fragment {
+	if a.re == "" {
+		return a
+	}
+	return fragment{group(a) + "?", false}
+}
+
+func concat(a, b fragment) fragment {
+	switch {
+	case a.re == "":
+		return b
+	case b.re == "":
+		return a
+	}
+	return fragment{a.re + b.re, false}
+}
+
+func kleene(a fragment) fragment {
+	if a.re == "" {
+		return a
+	}
+	return fragment{group(a) + "*", false}
+}
+
+//group returns the fragment ready to be quantified
+func group(a fragment) string {
+	if a.atomic {
+		return a.re
+	}
+	return "(?:" + a.re + ")"
+}
+
+//ToGoRegexp compiles the grex into a regexp that matches the symbol sequences encoded by enc.
+// New symbols are added to the encoder.
+func (g *Grex) ToGoRegexp(enc SymbolEncoder) (*regexp.Regexp, error) {
+	pattern, err := g.goPattern(enc)
+	if err != nil {
+		return nil, err
+	}
+	return regexp.Compile(pattern)
+}
+
+//goPattern computes the regexp pattern by state elimination
+func (g *Grex) goPattern(enc SymbolEncoder) (string, error) {
+	// symbols are allocated in a stable order
+	var names []string
+	for t := range g.graph.edges {
+		names = append(names, t.Name())
+	}
+	sort.Strings(names)
+	if err := enc.allocate(names); err != nil {
+		return "", err
+	}
+
+	// index the vertices, plus a new start, and a new end
+	vertices := g.graph.sortedVertices()
+	index := make(map[Vertex]int)
+	for i, v := range vertices {
+		index[v] = i
+	}
+	start, end := len(vertices), len(vertices)+1
+
+	// re[i][j] is the expression to go from i to j
+	re := make(map[int]map[int]fragment)
+	add := func(i, j int, f fragment) {
+		if re[i] == nil {
+			re[i] = make(map[int]fragment)
+		}
+		if old, ok := re[i][j]; ok {
+			f = union(old, f)
+		}
+		re[i][j] = f
+	}
+	for t, b := range g.graph.edges {
+		add(index[b.start], index[b.end], fragment{regexp.QuoteMeta(string(enc[t.Name()])), true})
+	}
+	add(start, index[g.in], fragment{})
+	for out := range g.outs {
+		add(index[out], end, fragment{})
+	}
+
+	for k := range vertices {
+		loop, looping := re[k][k]
+		for _, i := range sortedKeys(re) {
+			in, ok := re[i][k]
+			if !ok || i == k {
+				continue
+			}
+			if looping {
+				in = concat(in, kleene(loop))
+			}
+			for _, j := range sortedKeys(re[k]) {
+				if j != k {
+					add(i, j, concat(in, re[k][j]))
+				}
+			}
+			delete(re[i], k)
+		}
+		delete(re, k)
+	}
+
+	f, ok := re[start][end]
+	if !ok {
+		return `^[^\x00-\x{10FFFF}]$`, nil // matches nothing
+	}
+	return "^" + group(f) + "$", nil
+}
+
+//sortedKeys returns the keys of a map in increasing order, so that the pattern is always the same
+func sortedKeys(m interface{}) (keys []int) {
+	switch m := m.(type) {
+	case map[int]fragment:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[int]map[int]fragment:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Ints(keys)
+	return
+}
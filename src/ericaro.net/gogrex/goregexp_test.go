@@ -0,0 +1,67 @@
+package gogrex
+
+import (
+	"testing"
+)
+
+//sequences returns every sequence of symbols up to length n
+func sequences(symbols []string, n int) [][]string {
+	all := [][]string{{}}
+	last := [][]string{{}}
+	for i := 0; i < n; i++ {
+		var next [][]string
+		for _, s := range last {
+			for _, sym := range symbols {
+				next = append(next, append(append([]string{}, s...), sym))
+			}
+		}
+		all = append(all, next...)
+		last = next
+	}
+	return all
+}
+
+func TestToGoRegexp(t *testing.T) {
+	expressions := append([]string{"a|b", "(a,b)|(a,c)", "(a|b)*,c", "a?,b?,c?"}, exps[3:]...)
+	symbols := []string{"a", "b", "c", "x", "end"}
+	for _, exp := range expressions {
+		var m StringManager
+		g, err := ParseGrex(&m, exp)
+		if err != nil {
+			t.Fatalf("%s: %v", exp, err)
+		}
+		enc := make(SymbolEncoder)
+		re, err := g.ToGoRegexp(enc)
+		if err != nil {
+			t.Fatalf("%s: %v", exp, err)
+		}
+		for _, s := range sequences(symbols, 4) {
+			if g.Match(s) != re.MatchString(enc.Encode(s)) {
+				t.Errorf("%s on %v: %v disagrees with the grex", exp, s, re)
+			}
+		}
+	}
+}
+
+func TestSymbolEncoder(t *testing.T) {
+	var m StringManager
+	g, _ := ParseGrex(&m, "a, b, c")
+	// a pre-populated encoder keeps its runes, and new symbols get other ones
+	enc := SymbolEncoder{"b": firstPrivateRune, "x": firstPrivateRune + 2}
+	re, err := g.ToGoRegexp(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc["b"] != firstPrivateRune || enc["x"] != firstPrivateRune+2 {
+		t.Errorf("runes were changed %v", enc)
+	}
+	if len(enc) != 4 || enc["a"] == enc["c"] || enc["a"] == enc["x"] || enc["c"] == enc["x"] {
+		t.Errorf("runes are reused %v", enc)
+	}
+	if !re.MatchString(enc.Encode([]string{"a", "b", "c"})) || re.MatchString(enc.Encode([]string{"a", "b", "x"})) {
+		t.Errorf("%v does not match the encoded sequences", re)
+	}
+	if _, err := g.ToGoRegexp(SymbolEncoder{"a": 'a'}); err == nil {
+		t.Errorf("a rune outside of the private use area should be rejected")
+	}
+}
@@ -50,69 +50,133 @@ func (stack *itemStack) peek() (i Token, err error) {
 	return (*stack)[len(*stack)-1], nil
 }
 
-//shunting starts a go routine that read from the lex tokens, and push to the output chan. Tokens are simply reordered.
-//so usually you start this goroutine, and read from the output chan from another one.
-// if errors happens they are send to the err chan 
-func shunting(tokens chan Token) (output chan Token, err chan error) {
-	output = make(chan Token)
-	err = make(chan error)
-	go run(tokens, output, err)
-	return
+//tokenStream is a pull based source of tokens.
+type tokenStream interface {
+	//pull returns the next token, ok is false at the end of the stream.
+	pull() (t Token, ok bool)
 }
 
+//tokenSlice is a stream of already scanned tokens.
+type tokenSlice []Token
 
-//run execute the shunting yard algorithm ( http://en.wikipedia.org/wiki/Shunting-yard_algorithm ) (no function support)
-// it pops from the tokens, and write in the right order into the output chan, errors are pushed to the errchan
-func run(tokens chan Token, output chan Token, errchan chan error) {
-	stack := itemStack(make([]Token, 0, 10))
-	for token := range tokens {
-		switch {
-		case token.IsLeaf(): // usually a number in shunting yard, or an identifier
-			output <- token
-		//case token.IsFunction(): stack.push(token) // ignored for now, I don't need to support function call
-		//If the token is an operator, o1, then:
-		case token.IsOperator():
-			o2, err := stack.peek()
-			for err == nil && (( // while there is an operator token, o2,at the top of the stack, and
-			//o1 is left-associative and its precedence is less than or equal to that of o2,
-			token.IsLeftAssociative() && token.Precedence() <= o2.Precedence()) || (
-			//o1 has precedence less than that of o2,
-			token.Precedence() < o2.Precedence())) {
-				stack.pop()
-				output <- o2
-				o2, err = stack.peek()
-			}
-			stack.push(token)
-		//If the token is a left parenthesis, then push it onto the stack.
-		case token.IsLeftParenthesis():
-			//fmt.Printf("is left\n")
-			stack.push(token)
-		//If the token is a right parenthesis:
-		case token.IsRightParenthesis():
-			o2, err := stack.peek()
-			for err == nil && !o2.IsLeftParenthesis() { //Until the token at the top of the stack is a left parenthesis, 
-				//pop operators off the stack onto the output queue.
-				stack.pop()
-				output <- o2
-				o2, err = stack.peek()
-			}
-			if !o2.IsLeftParenthesis() {
-				errchan <- errors.New("parenthesis mismatch")
-			}
-			stack.pop()
+func (s *tokenSlice) pull() (t Token, ok bool) {
+	if len(*s) == 0 {
+		return nil, false
+	}
+	t, *s = (*s)[0], (*s)[1:]
+	return t, true
+}
+
+//shuntingYard reorders the tokens pulled from its input. Tokens are read one at a time, when the output needs them, so
+// nothing is left running when the reader stops early.
+type shuntingYard struct {
+	input  tokenStream
+	stack  itemStack
+	output []Token // reordered tokens, not yet read
+	err    error   // delivered once the output before it has been read
+	done   bool
+}
+
+//shunting creates the shunting yard for the tokens.
+func shunting(tokens tokenStream) *shuntingYard {
+	return &shuntingYard{input: tokens, stack: itemStack(make([]Token, 0, 10))}
+}
 
-			//		case token.typ.nature == typeEOF:
-			//			close(output)
+//next returns the next token in RPN order, or the error. Both are nil at the end.
+func (s *shuntingYard) next() (Token, error) {
+	for len(s.output) == 0 && !s.done {
+		s.step()
+	}
+	if len(s.output) == 0 {
+		return nil, s.err
+	}
+	t := s.output[0]
+	s.output = s.output[1:]
+	return t, nil
+}
+
+//emit appends a token to the output
+func (s *shuntingYard) emit(t Token) {
+	s.output = append(s.output, t)
+}
+
+//fail stops the algorithm with an error
+func (s *shuntingYard) fail(pos int, msg string) {
+	s.err = &ParseError{pos, msg}
+	s.done = true
+}
+
+//step executes the shunting yard algorithm ( http://en.wikipedia.org/wiki/Shunting-yard_algorithm ) (no function support)
+// on the next token: it pops from the tokens, and appends in the right order to the output
+func (s *shuntingYard) step() {
+	token, ok := s.input.pull()
+	if !ok {
+		s.flush()
+		return
+	}
+	stack := &s.stack
+	switch {
+	case isError(token): // lexer errors are passed through, in order
+		s.emit(token)
+	case token.IsLeaf(): // usually a number in shunting yard, or an identifier
+		s.emit(token)
+	//case token.IsFunction(): stack.push(token) // ignored for now, I don't need to support function call
+	//If the token is an operator, o1, then:
+	case token.IsOperator():
+		o2, err := stack.peek()
+		for err == nil && (( // while there is an operator token, o2,at the top of the stack, and
+		//o1 is left-associative and its precedence is less than or equal to that of o2,
+		token.IsLeftAssociative() && token.Precedence() <= o2.Precedence()) || (
+		//o1 has precedence less than that of o2,
+		token.Precedence() < o2.Precedence())) {
+			stack.pop()
+			s.emit(o2)
+			o2, err = stack.peek()
 		}
+		stack.push(token)
+	//If the token is a left parenthesis, then push it onto the stack.
+	case token.IsLeftParenthesis():
+		stack.push(token)
+	//If the token is a right parenthesis:
+	case token.IsRightParenthesis():
+		o2, err := stack.peek()
+		for err == nil && !o2.IsLeftParenthesis() { //Until the token at the top of the stack is a left parenthesis,
+			//pop operators off the stack onto the output queue.
+			stack.pop()
+			s.emit(o2)
+			o2, err = stack.peek()
+		}
+		if err != nil || !o2.IsLeftParenthesis() {
+			s.fail(position(token), "unmatched ')'")
+			return
+		}
+		stack.pop()
 	}
-	for len(stack) > 0 {
-		pop, err := stack.pop()
+}
+
+//flush pops the remaining operators at the end of the input
+func (s *shuntingYard) flush() {
+	for len(s.stack) > 0 {
+		pop, err := s.stack.pop()
 		if err != nil || pop.IsLeftParenthesis() || pop.IsRightParenthesis() {
-			errchan <- errors.New("parenthesis mismatch")
+			s.fail(position(pop), "unclosed '('")
+			return
 		} // this is an error
-		output <- pop
+		s.emit(pop)
 	}
-	close(output)
-	close(errchan)
-	return
+	s.done = true
+}
+
+//isError tells if the token is a lexer error
+func isError(t Token) bool {
+	i, ok := t.(item)
+	return ok && i.typ == itemError
+}
+
+//position returns the position of the token in the input, 0 if it is unknown
+func position(t Token) int {
+	if i, ok := t.(item); ok {
+		return i.pos
+	}
+	return 0
 }
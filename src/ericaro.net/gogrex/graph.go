@@ -1,7 +1,9 @@
 package gogrex
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
 )
 
 //Vertex represent any type that can act as a Vertex object
@@ -101,27 +103,24 @@ func (g *DirectedSparseMultigraph) Dest(t Edge) Vertex {
 	b := g.edges[t]
 	return b.end
 }
-//String print this graph in dot format. 'in' usually the input vertex and outs, have different labels, and shape (box)
-func (g *DirectedSparseMultigraph) String(in Vertex, outs map[Vertex]interface{}) string {
-	str := `digraph { size="6,4";rankdir=LR; ratio = fill; node [label="",shape=point,style=filled];
-	`
-
-	str += fmt.Sprintf(`%s [label="In",shape=box];
-	`, in)
-	for k := range outs {
-		if k == in {
-			str += fmt.Sprintf(`%s [label="IO",shape=box];
-	`, k)
-		} else {
-			str += fmt.Sprintf(`%s [label="Out",shape=box];
-	`, k)
-		}
+//sortedVertices returns the vertices sorted by their printed value, to get stable outputs.
+func (g *DirectedSparseMultigraph) sortedVertices() []Vertex {
+	vertices := make([]Vertex, 0, len(g.vertices))
+	for v := range g.vertices {
+		vertices = append(vertices, v)
 	}
+	sort.Slice(vertices, func(i, j int) bool { return vertexLess(vertices[i], vertices[j]) })
+	return vertices
+}
 
-	for t, b := range g.edges {
-		str += fmt.Sprintf(`%s -> %s [label="%s"];
-	`, b.start, b.end, t.Name())
-	}
-	str += "}"
-	return str
+//vertexLess orders vertices by their printed value
+func vertexLess(a, b Vertex) bool {
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+//String print this graph in dot format. 'in' usually the input vertex and outs, have different labels, and shape (box)
+func (g *DirectedSparseMultigraph) String(in Vertex, outs map[Vertex]interface{}) string {
+	var b bytes.Buffer
+	g.WriteDot(&b, in, outs, nil)
+	return b.String()
 }
@@ -0,0 +1,136 @@
+package gogrex
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// GraphML (yEd) and GEXF (Gephi) exports of the graph. Vertices are identified as n0, n1 ... and labelled with their
+// printed value, edges are labelled with their Name(). The input and output vertices have boolean attributes.
+
+//errWriter writes formatted strings, and keeps the first error
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) printf(format string, args ...interface{}) {
+	if e.err == nil {
+		_, e.err = fmt.Fprintf(e.w, format, args...)
+	}
+}
+
+//escape returns the string escaped for XML text and attributes
+func escape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+//indexedEdge is an edge with the index of its bounds
+type indexedEdge struct {
+	edge     Edge
+	from, to int
+}
+
+//indexed returns the vertices in a stable order, and the edges with their bounds index, sorted by bounds then names.
+func (g *DirectedSparseMultigraph) indexed() ([]Vertex, []indexedEdge) {
+	vertices := g.sortedVertices()
+	index := make(map[Vertex]int)
+	for i, v := range vertices {
+		index[v] = i
+	}
+	edges := make([]indexedEdge, 0, len(g.edges))
+	for t, b := range g.edges {
+		edges = append(edges, indexedEdge{t, index[b.start], index[b.end]})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if a.from != b.from {
+			return a.from < b.from
+		}
+		if a.to != b.to {
+			return a.to < b.to
+		}
+		return a.edge.Name() < b.edge.Name()
+	})
+	return vertices, edges
+}
+
+//WriteGraphML writes the graph in GraphML format. 'in' and 'outs' are marked with the "input" and "output" attributes.
+func (g *DirectedSparseMultigraph) WriteGraphML(w io.Writer, in Vertex, outs map[Vertex]interface{}) error {
+	vertices, edges := g.indexed()
+	e := &errWriter{w: w}
+	e.printf(`<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="label" for="node" attr.name="label" attr.type="string"/>
+  <key id="input" for="node" attr.name="input" attr.type="boolean"><default>false</default></key>
+  <key id="output" for="node" attr.name="output" attr.type="boolean"><default>false</default></key>
+  <key id="name" for="edge" attr.name="label" attr.type="string"/>
+  <graph id="G" edgedefault="directed">
+`)
+	for i, v := range vertices {
+		e.printf(`    <node id="n%d"><data key="label">%s</data>`, i, escape(fmt.Sprint(v)))
+		if v == in {
+			e.printf(`<data key="input">true</data>`)
+		}
+		if _, ok := outs[v]; ok {
+			e.printf(`<data key="output">true</data>`)
+		}
+		e.printf("</node>\n")
+	}
+	for i, t := range edges {
+		e.printf(`    <edge id="e%d" source="n%d" target="n%d"><data key="name">%s</data></edge>`+"\n", i, t.from, t.to, escape(t.edge.Name()))
+	}
+	e.printf("  </graph>\n</graphml>\n")
+	return e.err
+}
+
+//WriteGEXF writes the graph in GEXF format. 'in' and 'outs' are marked with the "input" and "output" attributes.
+func (g *DirectedSparseMultigraph) WriteGEXF(w io.Writer, in Vertex, outs map[Vertex]interface{}) error {
+	vertices, edges := g.indexed()
+	e := &errWriter{w: w}
+	e.printf(`<?xml version="1.0" encoding="UTF-8"?>
+<gexf xmlns="http://www.gexf.net/1.2draft" version="1.2">
+  <graph mode="static" defaultedgetype="directed">
+    <attributes class="node">
+      <attribute id="input" title="input" type="boolean"><default>false</default></attribute>
+      <attribute id="output" title="output" type="boolean"><default>false</default></attribute>
+    </attributes>
+    <nodes>
+`)
+	for i, v := range vertices {
+		e.printf(`      <node id="n%d" label="%s">`, i, escape(fmt.Sprint(v)))
+		_, out := outs[v]
+		if v == in || out {
+			e.printf("<attvalues>")
+			if v == in {
+				e.printf(`<attvalue for="input" value="true"/>`)
+			}
+			if out {
+				e.printf(`<attvalue for="output" value="true"/>`)
+			}
+			e.printf("</attvalues>")
+		}
+		e.printf("</node>\n")
+	}
+	e.printf("    </nodes>\n    <edges>\n")
+	for i, t := range edges {
+		e.printf(`      <edge id="e%d" source="n%d" target="n%d" label="%s"/>`+"\n", i, t.from, t.to, escape(t.edge.Name()))
+	}
+	e.printf("    </edges>\n  </graph>\n</gexf>\n")
+	return e.err
+}
+
+//WriteGraphML writes the grex graph in GraphML format.
+func (g *Grex) WriteGraphML(w io.Writer) error {
+	return g.graph.WriteGraphML(w, g.in, g.outs)
+}
+
+//WriteGEXF writes the grex graph in GEXF format.
+func (g *Grex) WriteGEXF(w io.Writer) error {
+	return g.graph.WriteGEXF(w, g.in, g.outs)
+}
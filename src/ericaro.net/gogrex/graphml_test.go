@@ -0,0 +1,74 @@
+package gogrex
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+)
+
+func TestGraphMLAndGEXF(t *testing.T) {
+	var m StringManager
+	g, err := ParseGrex(&m, "a, (b|c)*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var graphml struct {
+		Nodes []struct {
+			ID   string `xml:"id,attr"`
+			Data []struct {
+				Key   string `xml:"key,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"data"`
+		} `xml:"graph>node"`
+		Edges []struct {
+			Source string `xml:"source,attr"`
+			Target string `xml:"target,attr"`
+			Name   string `xml:"data"`
+		} `xml:"graph>edge"`
+	}
+	var b bytes.Buffer
+	if err := g.WriteGraphML(&b); err != nil {
+		t.Fatal(err)
+	}
+	if err := xml.Unmarshal(b.Bytes(), &graphml); err != nil {
+		t.Fatalf("invalid GraphML: %v\n%s", err, b.String())
+	}
+	if len(graphml.Nodes) != len(g.Vertices()) || len(graphml.Edges) != len(g.Edges()) {
+		t.Errorf("unexpected GraphML size\n%s", b.String())
+	}
+	inputs, outputs := 0, 0
+	for _, n := range graphml.Nodes {
+		for _, d := range n.Data {
+			if d.Key == "input" {
+				inputs++
+			}
+			if d.Key == "output" {
+				outputs++
+			}
+		}
+	}
+	if inputs != 1 || outputs != len(g.OutputVertice()) {
+		t.Errorf("expected 1 input and %d outputs, got %d and %d", len(g.OutputVertice()), inputs, outputs)
+	}
+
+	var gexf struct {
+		Nodes []struct {
+			ID    string `xml:"id,attr"`
+			Label string `xml:"label,attr"`
+		} `xml:"graph>nodes>node"`
+		Edges []struct {
+			Label string `xml:"label,attr"`
+		} `xml:"graph>edges>edge"`
+	}
+	b.Reset()
+	if err := g.WriteGEXF(&b); err != nil {
+		t.Fatal(err)
+	}
+	if err := xml.Unmarshal(b.Bytes(), &gexf); err != nil {
+		t.Fatalf("invalid GEXF: %v\n%s", err, b.String())
+	}
+	if len(gexf.Nodes) != len(g.Vertices()) || len(gexf.Edges) != len(g.Edges()) {
+		t.Errorf("unexpected GEXF size\n%s", b.String())
+	}
+}
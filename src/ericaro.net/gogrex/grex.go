@@ -1,32 +1,10 @@
 package gogrex
 
 import (
-	"errors"
 	"fmt"
 	"strings"
 )
 
-type grexStack []*Grex
-
-func (stack *grexStack) Pop() (i *Grex, err error) {
-	i, err = stack.Peek()
-	if err == nil {
-		*stack = (*stack)[:len(*stack)-1]
-	}
-	return
-}
-
-func (stack *grexStack) Push(x *Grex) {
-	*stack = append(*stack, x)
-}
-
-func (stack *grexStack) Peek() (i *Grex, err error) {
-	if len(*stack) == 0 {
-		return i, errors.New("Empty Stack")
-	}
-	return (*stack)[len(*stack)-1], nil
-}
-
 // a grex is not a regular graph, is a graph build by a regular expression
 
 // manage state and transitions (creation and duplication)
@@ -76,6 +54,16 @@ type Grex struct {
 	outs    map[Vertex]interface{} //a grex has several outputs vertice. We use a map for that purpose
 
 	graph *DirectedSparseMultigraph // the graph for real.
+	spans map[Edge]Span // the position in the expression of the identifier each edge comes from.
+}
+
+//Span is a byte range [Start, End) in the source expression
+type Span struct {
+	Start, End int
+}
+
+func (s Span) String() string {
+	return fmt.Sprintf("%d:%d", s.Start, s.End)
 }
 
 func (g *Grex) String() string {
@@ -87,19 +75,18 @@ func NewGrex(m Manager) *Grex {
 	return &Grex{
 		outs:    make(map[Vertex]interface{}),
 		graph:   NewDirectedSparseMultigraph(),
+		spans:   make(map[Edge]Span),
 		manager: m,
 	}
 }
 
 //terminal is called when parsing a indentifier, in charge to build a basic grex made of a single edge
-func terminal(m Manager, name string) *Grex {
-	g := NewGrex(m)
-	t := m.NewEdge(name)
-	s1 := m.NewVertex()
-	s2 := m.NewVertex()
-	g.graph.AddEdge(t, s1, s2)
-	g.in = s1
-	g.outs[s2] = nil
+// span is the position of the identifier in the expression
+func terminal(m Manager, name string, span Span) *Grex {
+	g := Terminal(m, name)
+	for t := range g.graph.edges { // there is only one
+		g.spans[t] = span
+	}
 	return g
 }
 
@@ -214,6 +201,15 @@ func opt(this *Grex) *Grex {
 	return n
 }
 
+//empty returns a new Grex that only accepts the empty sequence: a single vertex, both input and output.
+func empty(m Manager) *Grex {
+	g := NewGrex(m)
+	g.in = m.NewVertex()
+	g.graph.AddVertex(g.in)
+	g.outs[g.in] = nil
+	return g
+}
+
 //star returns a new Grex result of  ( this )*
 // here we cheated, ()* is implemented as ()+?
 func star(this *Grex) *Grex {
@@ -267,6 +263,13 @@ func (g *Grex) Vertices() (vertices []Vertex) {
 	return
 }
 
+//Span returns the position in the source expression of the identifier that produced the edge.
+//ok is false if the edge was not built by parsing an expression.
+func (g *Grex) Span(e Edge) (span Span, ok bool) {
+	span, ok = g.spans[e]
+	return
+}
+
 //Edges returns a map of all Edges, and their Bounds ( a simple pair (Source, Dest)
 func (g *Grex) Edges() map[Edge]Bounds {
 	return g.graph.edges
@@ -325,6 +328,9 @@ func (g *Grex) copyGraphInto(target *Grex) map[Vertex]Vertex {
 	for t, b := range g.graph.edges {
 		tclone := target.manager.CloneEdge(t)
 		target.graph.AddEdge(tclone, m[b.start], m[b.end])
+		if span, ok := g.spans[t]; ok {
+			target.spans[tclone] = span
+		}
 	}
 	return m
 }
@@ -334,7 +340,11 @@ func (g *Grex) mergeOutbounds(src, dest Vertex) {
 	// copy oldout  outbonds into source
 	for t, bounds := range g.graph.edges {
 		if bounds.start == src {
-			g.graph.AddEdge(g.manager.CloneEdge(t), dest, bounds.end)
+			clone := g.manager.CloneEdge(t)
+			g.graph.AddEdge(clone, dest, bounds.end)
+			if span, ok := g.spans[t]; ok {
+				g.spans[clone] = span
+			}
 		}
 	}
 }
@@ -354,83 +364,15 @@ func (g *Grex) dup() *Grex {
 
 //ParseGrex parses the regexp, and build a new Grex, using the Manager
 func ParseGrex(m Manager, regexp string) (grex *Grex, err error) {
-	tokens := lex(regexp) // build a lexer
-	grammar, errchan := shunting(tokens) // start the shuntingYard
-	
-	// now parses the expression in a RPN notation
-	var stack grexStack  // as any RPN interpreter I need a stack
-	var t Token
-	for {
-		t, err = nil, nil
-		select { // that's a bit ugly to my taste
-		case t = <-grammar:
-		case err = <-errchan:
-		} //get a correct token, or an error
-		if t == nil && err == nil { // end detected
-			this, err := stack.Pop() // return the last item in the stack
-			if err != nil {
-				return nil, err
-			}
-			return this, nil
-		}
-		if err != nil { // not the end, but an error though
-			return
-		}
-		i := t.(item) // now I've got an item
-		switch i.typ { // operates,
-		case itemStar: // mono operand: pop, star
-			this, err := stack.Pop()
-			if err != nil {
-				return nil, err
-			}
-			this = star(this)
-			stack.Push(this)
-		case itemPlus: // mono operand : pop, plus
-			this, err := stack.Pop()
-			if err != nil {
-				return nil, err
-			}
-			this = plus(this)
-			stack.Push(this)
-		case itemOpt: //mono operand : pop, star
-			this, err := stack.Pop()
-			if err != nil {
-				return nil, err
-			}
-			this = opt(this)
-			stack.Push(this)
-		case itemSel: // binary operand: pop, pop, sel
-			b, err := stack.Pop()
-			if err != nil {
-				return nil, err
-			}
-			a, err := stack.Pop()
-			if err != nil {
-				return nil, err
-			}
-			this := sel(a, b)
-			stack.Push(this)
-		case itemSeq:// binary operand: pop, pop, sel
-			b, err := stack.Pop()
-			if err != nil {
-				return nil, err
-			}
-			a, err := stack.Pop()
-			if err != nil {
-				return nil, err
-			}
-			this := seq(a, b)
-			stack.Push(this)
-		case itemIdentifier:// leaf element, creates a new single edge graph
-			this := terminal(m, i.val)
-			stack.Push(this)
-		case itemError: // a lex error has occured
-			err = errors.New(i.val)
-			return
-		default: // unexpected token
-			err = errors.New(fmt.Sprintf("Invalid Token %s.", i.val))
-			return
-		}
+	return build(m, lex(regexp)) // build a lexer, and interpret its tokens
+}
+
+//build parses the tokens into an expression, and builds a new Grex from it, using the Manager.
+// tokens can come from any source, as long as they are items.
+func build(m Manager, tokens tokenStream) (*Grex, error) {
+	e, err := parse(tokens)
+	if err != nil {
+		return nil, err
 	}
-	return
+	return e.Grex(m), nil
 }
@@ -0,0 +1,125 @@
+package gogrex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// The JSON encoding of a grex only keeps its structure: vertices are numbered from 0, and edges refer to them.
+// Vertices and edges are recreated by the Manager when decoding, so the grex must be created with NewGrex first:
+//
+//	g := NewGrex(&m)
+//	err := json.Unmarshal(data, g)
+//
+//	{"version":1,"vertices":3,"in":0,"outs":[2],"edges":[{"name":"a","from":0,"to":1,"span":{"start":0,"end":1}}, ...]}
+
+//jsonVersion is the version of the JSON encoding written by MarshalJSON
+const jsonVersion = 1
+
+type jsonGrex struct {
+	Version  int        `json:"version"`
+	Vertices int        `json:"vertices"`
+	In       int        `json:"in"`
+	Outs     []int      `json:"outs"`
+	Edges    []jsonEdge `json:"edges"`
+}
+
+type jsonEdge struct {
+	Name string    `json:"name"`
+	From int       `json:"from"`
+	To   int       `json:"to"`
+	Span *jsonSpan `json:"span,omitempty"`
+}
+
+type jsonSpan struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+//MarshalJSON encodes the grex structure. The output is stable: vertices are numbered in breadth first order from the input, and edges are sorted, spans included.
+func (g *Grex) MarshalJSON() ([]byte, error) {
+	vertices, _ := g.orderedVertices()
+	index := make(map[Vertex]int)
+	for i, v := range vertices {
+		index[v] = i
+	}
+	j := jsonGrex{Version: jsonVersion, Vertices: len(vertices), In: index[g.in], Outs: []int{}, Edges: []jsonEdge{}}
+	for out := range g.outs {
+		j.Outs = append(j.Outs, index[out])
+	}
+	sort.Ints(j.Outs)
+	for t, b := range g.graph.edges {
+		e := jsonEdge{Name: t.Name(), From: index[b.start], To: index[b.end]}
+		if span, ok := g.spans[t]; ok {
+			e.Span = &jsonSpan{span.Start, span.End}
+		}
+		j.Edges = append(j.Edges, e)
+	}
+	sort.Slice(j.Edges, func(a, b int) bool {
+		ea, eb := j.Edges[a], j.Edges[b]
+		if ea.From != eb.From {
+			return ea.From < eb.From
+		}
+		if ea.To != eb.To {
+			return ea.To < eb.To
+		}
+		if ea.Name != eb.Name {
+			return ea.Name < eb.Name
+		}
+		if ea.Span == nil || eb.Span == nil {
+			return ea.Span == nil && eb.Span != nil
+		}
+		return ea.Span.Start < eb.Span.Start || ea.Span.Start == eb.Span.Start && ea.Span.End < eb.Span.End
+	})
+	return json.Marshal(j)
+}
+
+//UnmarshalJSON replaces the grex content by the decoded one. Vertices and edges are created by the grex Manager.
+func (g *Grex) UnmarshalJSON(data []byte) error {
+	if g.manager == nil {
+		return errors.New("gogrex: cannot decode into a grex without Manager, use NewGrex")
+	}
+	var j jsonGrex
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if j.Version != jsonVersion {
+		return errors.New(fmt.Sprintf("gogrex: unsupported json version %d", j.Version))
+	}
+	// every vertex but the input is an output or the end of an edge: the count cannot be larger
+	if j.Vertices > 1+len(j.Outs)+2*len(j.Edges) {
+		return errors.New(fmt.Sprintf("gogrex: %d vertices for %d outputs and %d edges", j.Vertices, len(j.Outs), len(j.Edges)))
+	}
+	valid := func(v int) bool { return v >= 0 && v < j.Vertices }
+	if !valid(j.In) {
+		return errors.New(fmt.Sprintf("gogrex: invalid input vertex %d", j.In))
+	}
+
+	n := NewGrex(g.manager)
+	vertices := make([]Vertex, j.Vertices)
+	for i := range vertices {
+		vertices[i] = g.manager.NewVertex()
+		n.graph.AddVertex(vertices[i])
+	}
+	n.in = vertices[j.In]
+	for _, out := range j.Outs {
+		if !valid(out) {
+			return errors.New(fmt.Sprintf("gogrex: invalid output vertex %d", out))
+		}
+		n.outs[vertices[out]] = nil
+	}
+	for _, e := range j.Edges {
+		if !valid(e.From) || !valid(e.To) {
+			return errors.New(fmt.Sprintf("gogrex: invalid edge %s from %d to %d", e.Name, e.From, e.To))
+		}
+		t := g.manager.NewEdge(e.Name)
+		n.graph.AddEdge(t, vertices[e.From], vertices[e.To])
+		if e.Span != nil {
+			n.spans[t] = Span{e.Span.Start, e.Span.End}
+		}
+	}
+	*g = *n
+	return nil
+}
@@ -0,0 +1,77 @@
+package gogrex
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	for _, exp := range exps {
+		var m StringManager
+		g, err := ParseGrex(&m, exp)
+		if err != nil {
+			t.Fatalf("%s: %v", exp, err)
+		}
+		data, err := json.Marshal(g)
+		if err != nil {
+			t.Fatalf("%s: %v", exp, err)
+		}
+		var other StringManager
+		loaded := NewGrex(&other)
+		if err := json.Unmarshal(data, loaded); err != nil {
+			t.Fatalf("%s: %v", exp, err)
+		}
+		again, err := json.Marshal(loaded)
+		if err != nil {
+			t.Fatalf("%s: %v", exp, err)
+		}
+		if string(again) != string(data) {
+			t.Errorf("%s: round trip changed the encoding\n%s\n%s", exp, data, again)
+		}
+		if len(loaded.Ambiguities()) != len(g.Ambiguities()) {
+			t.Errorf("%s: spans were lost", exp)
+		}
+		for _, s := range sequences([]string{"a", "b", "c", "x"}, 3) {
+			if g.Match(s) != loaded.Match(s) {
+				t.Errorf("%s on %v: the loaded grex is not equivalent", exp, s)
+			}
+		}
+	}
+
+	invalids := []string{
+		`{"version":2,"vertices":1,"in":0}`,
+		`{"version":1,"vertices":1,"in":1}`,
+		`{"version":1,"vertices":2,"in":0,"outs":[0],"edges":[{"name":"a","from":0,"to":2}]}`,
+		`{"version":1,"vertices":1000000000000,"in":0}`,
+		`{"version":1,"vertices":-1,"in":0}`,
+	}
+	for _, data := range invalids {
+		var m StringManager
+		if err := json.Unmarshal([]byte(data), NewGrex(&m)); err == nil {
+			t.Errorf("%s: expected an error", data)
+		}
+	}
+	if err := json.Unmarshal([]byte(`{"version":1,"vertices":1,"in":0}`), &Grex{}); err == nil {
+		t.Errorf("decoding without Manager should fail")
+	}
+}
+
+func TestJSONStable(t *testing.T) {
+	// the a edges are told apart by their target, then by their span
+	for _, exp := range []string{"(a,c)|(a,b)|(a,b?,a)", "(a,b)|(a,b)"} {
+		var expected []byte
+		for i := 0; i < 100; i++ {
+			var m StringManager
+			g, _ := ParseGrex(&m, exp)
+			data, err := json.Marshal(g)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected == nil {
+				expected = data
+			} else if string(data) != string(expected) {
+				t.Fatalf("%s, run %d: the encoding changed\n%s\n%s", exp, i, expected, data)
+			}
+		}
+	}
+}
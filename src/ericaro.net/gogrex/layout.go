@@ -0,0 +1,195 @@
+package gogrex
+
+import (
+	"sort"
+)
+
+// Layered layout of the graph, in the Sugiyama style:
+//  1. cycles are broken by reversing the back edges of a depth first search from the input
+//  2. vertices are assigned to layers by longest path, long edges are split by dummy nodes, one per layer crossed
+//  3. crossings are reduced by barycenter sweeps over the layers
+//  4. layers become columns from left to right, like rankdir=LR
+// Like orderedVertices, the layout only depends on the structure of the grex: vertices, and the outbounds of every
+// vertex, are visited in sortedOutEdges order.
+
+const (
+	layoutLayerGap = 90.0 // distance between two layers
+	layoutRowGap   = 50.0 // distance between two nodes of the same layer
+	layoutMargin   = 40.0
+	layoutSweeps   = 8
+)
+
+//layoutNode is a vertex, or a dummy node of a long edge, positioned in the drawing
+type layoutNode struct {
+	vertex Vertex // nil for dummy nodes
+	layer  int
+	x, y   float64
+}
+
+//layoutEdge is an edge drawn through a list of nodes
+type layoutEdge struct {
+	edge     Edge
+	nodes    []int // indexes of the nodes, in layer order: a self loop has a single node
+	reversed bool  // the edge goes from the last node to the first one
+}
+
+//layout is the result of the layered layout. Vertices come first in nodes, in orderedVertices order.
+type layout struct {
+	nodes         []layoutNode
+	edges         []layoutEdge
+	width, height float64
+}
+
+//layout computes the layered layout of the grex
+func (g *Grex) layout() *layout {
+	vertices, out := g.orderedVertices() // the sorted outbounds are computed once, sortedOutEdges scans every edge
+	index := make(map[Vertex]int)
+	l := &layout{}
+	for i, v := range vertices {
+		index[v] = i
+		l.nodes = append(l.nodes, layoutNode{vertex: v})
+	}
+
+	// depth first search, edges to a vertex being visited are back edges
+	const (
+		white = iota
+		grey
+		black
+	)
+	color := make([]int, len(vertices))
+	var post []int
+	back := make(map[Edge]bool)
+	var visit func(i int)
+	visit = func(i int) {
+		color[i] = grey
+		for _, t := range out[vertices[i]] {
+			j := index[g.graph.Dest(t)]
+			switch color[j] {
+			case white:
+				visit(j)
+			case grey:
+				back[t] = true
+			}
+		}
+		color[i] = black
+		post = append(post, i)
+	}
+	for i := range vertices {
+		if color[i] == white {
+			visit(i)
+		}
+	}
+	// the reverse post order is a topological order once back edges are reversed
+	rank := make([]int, len(vertices))
+	for k, i := range post {
+		rank[i] = len(post) - 1 - k
+	}
+
+	type arc struct {
+		edge     Edge
+		from, to int
+	}
+	var arcs []arc
+	for i, v := range vertices {
+		for _, t := range out[v] {
+			from, to := i, index[g.graph.Dest(t)]
+			if back[t] {
+				from, to = to, from
+			}
+			arcs = append(arcs, arc{t, from, to})
+		}
+	}
+
+	// longest path layering: arcs are relaxed in the topological order of their source
+	sorted := append([]arc(nil), arcs...)
+	sort.SliceStable(sorted, func(a, b int) bool { return rank[sorted[a].from] < rank[sorted[b].from] })
+	for _, a := range sorted {
+		if a.from != a.to && l.nodes[a.to].layer < l.nodes[a.from].layer+1 {
+			l.nodes[a.to].layer = l.nodes[a.from].layer + 1
+		}
+	}
+
+	// long edges are split by dummy nodes
+	for _, a := range arcs {
+		e := layoutEdge{edge: a.edge, nodes: []int{a.from}, reversed: back[a.edge]}
+		if a.from != a.to {
+			for k := l.nodes[a.from].layer + 1; k < l.nodes[a.to].layer; k++ {
+				e.nodes = append(e.nodes, len(l.nodes))
+				l.nodes = append(l.nodes, layoutNode{layer: k})
+			}
+			e.nodes = append(e.nodes, a.to)
+		}
+		l.edges = append(l.edges, e)
+	}
+
+	// neighbors in the previous and next layers
+	prev := make([][]int, len(l.nodes))
+	next := make([][]int, len(l.nodes))
+	for _, e := range l.edges {
+		for k := 1; k < len(e.nodes); k++ {
+			a, b := e.nodes[k-1], e.nodes[k]
+			next[a] = append(next[a], b)
+			prev[b] = append(prev[b], a)
+		}
+	}
+	var layers [][]int
+	for n, node := range l.nodes {
+		for len(layers) <= node.layer {
+			layers = append(layers, nil)
+		}
+		layers[node.layer] = append(layers[node.layer], n)
+	}
+
+	// crossing reduction: nodes are sorted by the mean position of their neighbors, alternating down and up sweeps
+	pos := make([]float64, len(l.nodes))
+	for _, layer := range layers {
+		for p, n := range layer {
+			pos[n] = float64(p)
+		}
+	}
+	order := func(layer []int, neighbors [][]int) {
+		barycenter := make(map[int]float64)
+		for _, n := range layer {
+			barycenter[n] = pos[n]
+			if len(neighbors[n]) > 0 {
+				sum := 0.0
+				for _, m := range neighbors[n] {
+					sum += pos[m]
+				}
+				barycenter[n] = sum / float64(len(neighbors[n]))
+			}
+		}
+		sort.SliceStable(layer, func(a, b int) bool { return barycenter[layer[a]] < barycenter[layer[b]] })
+		for p, n := range layer {
+			pos[n] = float64(p)
+		}
+	}
+	for s := 0; s < layoutSweeps; s++ {
+		if s%2 == 0 {
+			for k := 1; k < len(layers); k++ {
+				order(layers[k], prev)
+			}
+		} else {
+			for k := len(layers) - 2; k >= 0; k-- {
+				order(layers[k], next)
+			}
+		}
+	}
+
+	// coordinates: layers are columns, centered vertically
+	rows := 0
+	for _, layer := range layers {
+		if len(layer) > rows {
+			rows = len(layer)
+		}
+	}
+	for k, layer := range layers {
+		for p, n := range layer {
+			l.nodes[n].x = layoutMargin + float64(k)*layoutLayerGap
+			l.nodes[n].y = layoutMargin + (float64(p)+float64(rows-len(layer))/2)*layoutRowGap
+		}
+	}
+	l.width = 2*layoutMargin + float64(len(layers)-1)*layoutLayerGap
+	l.height = 2*layoutMargin + float64(rows-1)*layoutRowGap
+	return l
+}
@@ -53,6 +53,7 @@ type itemType struct {
 type item struct {
 	typ itemType // Type, such as itemNumber.
 	val string   // Value, such as "23.2".
+	pos int      // byte offset of the item in the input.
 }
 
 //Token implementation
@@ -67,11 +68,12 @@ func (i item) Precedence() int          { return i.typ.precedence } // does not
 //func (i item) isFunction()bool { return false} // no function in this language
 
 type lexer struct {
-	input string     // the string being scanned.
-	start int        // start position of this item.
-	pos   int        // current position in the input.
-	width int        // width of last rune read from input.
-	items chan Token // channel of scanned items.
+	input string  // the string being scanned.
+	start int     // start position of this item.
+	pos   int     // current position in the input.
+	width int     // width of last rune read from input.
+	state stateFn // next state, nil once the input is consumed.
+	items []Token // scanned items, not yet pulled.
 }
 
 // stateFn represents the state of the scanner
@@ -102,29 +104,32 @@ func (i item) String() string {
 	default:
 		return i.val
 	}
-	return ""
 }
 
 // emit passes an item back to the client.
 func (l *lexer) emit(t itemType) {
-	l.items <- item{t, l.input[l.start:l.pos]}
+	l.items = append(l.items, item{t, l.input[l.start:l.pos], l.start})
 	l.start = l.pos
 }
 
-// lex creates a new scanner for the input string.
-func lex(input string) chan Token {
-	l := &lexer{
+// lex creates a new scanner for the input string. Items are scanned on demand, when they are pulled.
+func lex(input string) *lexer {
+	return &lexer{
 		input: input,
-		items: make(chan Token, 2), // Two items sufficient.
+		state: lexText,
 	}
-	go l.run()
-	return l.items
 }
-func (l *lexer) run() {
-	for state := lexText; state != nil; {
-		state = state(l)
+
+//pull runs the state machine until an item is scanned, ok is false once the input is consumed.
+func (l *lexer) pull() (t Token, ok bool) {
+	for len(l.items) == 0 {
+		if l.state == nil {
+			return nil, false // No more tokens will be delivered.
+		}
+		l.state = l.state(l)
 	}
-	close(l.items) // No more tokens will be delivered.
+	t, l.items = l.items[0], l.items[1:]
+	return t, true
 }
 
 // next returns the next rune in the input.
@@ -153,10 +158,11 @@ func (l *lexer) backup() {
 // by passing back a nil pointer that will be the next
 // state, terminating l.run.
 func (l *lexer) errorf(format string, args ...interface{}) stateFn {
-	l.items <- item{
+	l.items = append(l.items, item{
 		itemError,
 		fmt.Sprintf(format, args...),
-	}
+		l.start,
+	})
 	return nil
 }
 
@@ -182,6 +188,7 @@ func lexText(l *lexer) stateFn {
 			l.emit(itemRight)
 		case unicode.IsSpace(r): // auto ignored
 			l.ignore()
+			continue
 		case unicode.IsLetter(r) || r== '_':
 			return lexIdentifier // now read an identifier
 		case r == '/': // comment start
@@ -192,13 +199,13 @@ func lexText(l *lexer) stateFn {
 			case r == '*':
 				return lexMultiLineComment
 			default:
-				l.errorf("invalid comment start /%s", r)
+				return l.errorf("invalid comment start /%q", r)
 			}
 		default:
-			l.errorf("Unknown character %s", r)
+			return l.errorf("Unknown character %q", r)
 		}
+		return lexText // one item at a time
 	}
-	return nil // Stop the run loop.
 }
 
 func lexIdentifier(l *lexer) stateFn {
@@ -210,14 +217,16 @@ func lexIdentifier(l *lexer) stateFn {
 }
 
 func lexSingleLineComment(l *lexer) stateFn {
-	for r := l.next(); r != '\n'; r = l.next() {
+	for r := l.next(); r != '\n' && r != eof; r = l.next() {
 	}
 	l.emit(itemComment)
 	return lexText
 }
 func lexMultiLineComment(l *lexer) stateFn {
-	p := l.next()
-	for r := l.next(); p != '*' && r != '/'; r = l.next() {
+	for p, r := rune(0), l.next(); p != '*' || r != '/'; p, r = r, l.next() {
+		if r == eof {
+			return l.errorf("unterminated comment")
+		}
 	}
 
 	l.emit(itemComment)
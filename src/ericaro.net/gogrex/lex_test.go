@@ -252,13 +252,13 @@ func aTestLex(t *testing.T) {
 
 		tokens := lex(s)
 
-		for to := range tokens {
+		for to, ok := tokens.pull(); ok; to, ok = tokens.pull() {
 			// below to generate goldens
 			//fmt.Printf("item{typ:%s,val:\"%s\"},\n", typs[i.typ], i.val)
 			g := goldens[j][k]
 			i := to.(item)
 			if i.typ != g.typ || i.val != g.val {
-				t.Fatal("unexpected Output %v %v %v", i, g.typ, g.val)
+				t.Fatalf("unexpected Output %v %v %v", i, g.typ, g.val)
 			}
 			k++
 			//typ, val := i.typ, i.val
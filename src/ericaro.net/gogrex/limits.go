@@ -0,0 +1,291 @@
+package gogrex
+
+import (
+	"context"
+	"fmt"
+)
+
+// Expressions can come from untrusted users: a few bytes can build a large graph, and determinizing a graph can be
+// exponential. Limits bound the work, and every step checks them, and the cancellation of the context, before it
+// allocates.
+
+//Limits bounds the resources used to build a grex. A zero field means no limit.
+type Limits struct {
+	MaxVertices int // vertices created to build a grex, copies included, and states of a determinized grex
+	MaxEdges    int // edges created to build a grex, copies included, and transitions of a determinized grex
+	MaxDepth    int // nesting of the operators, a chain of "," or of "|" counts once
+	MaxLength   int // bytes of the expression
+}
+
+//ErrLimitExceeded is the error returned when a limit is exceeded.
+type ErrLimitExceeded struct {
+	Limit string // the name of the Limits field
+	Max   int    // its value
+	Value int    // the value that exceeds it
+}
+
+func (e *ErrLimitExceeded) Error() string {
+	return fmt.Sprintf("gogrex: %s exceeded: %d > %d", e.Limit, e.Value, e.Max)
+}
+
+//limiter checks the limits, and the context. A nil limiter checks nothing.
+type limiter struct {
+	ctx      context.Context
+	limits   Limits
+	vertices int // vertices created so far, by add
+	edges    int // edges created so far, by add
+}
+
+//check tells if a graph of that size can be built
+func (l *limiter) check(vertices, edges int) error {
+	if l == nil {
+		return nil
+	}
+	if err := l.ctx.Err(); err != nil {
+		return err
+	}
+	if l.limits.MaxVertices > 0 && vertices > l.limits.MaxVertices {
+		return &ErrLimitExceeded{"MaxVertices", l.limits.MaxVertices, vertices}
+	}
+	if l.limits.MaxEdges > 0 && edges > l.limits.MaxEdges {
+		return &ErrLimitExceeded{"MaxEdges", l.limits.MaxEdges, edges}
+	}
+	return nil
+}
+
+//add counts vertices and edges about to be created, and checks the totals so far
+func (l *limiter) add(vertices, edges int) error {
+	if l == nil {
+		return nil
+	}
+	l.vertices += vertices
+	l.edges += edges
+	return l.check(l.vertices, l.edges)
+}
+
+//err checks the context only, it is cheap enough to be called inside loops
+func (l *limiter) err() error {
+	if l == nil {
+		return nil
+	}
+	return l.ctx.Err()
+}
+
+//ParseGrexContext parses the regexp, and builds a new Grex using the Manager, within the limits. It stops as soon as
+// the context is done, and returns its error.
+func ParseGrexContext(ctx context.Context, m Manager, regexp string, limits Limits) (*Grex, error) {
+	if limits.MaxLength > 0 && len(regexp) > limits.MaxLength {
+		return nil, &ErrLimitExceeded{"MaxLength", limits.MaxLength, len(regexp)}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	e, err := ParseExpr(regexp)
+	if err != nil {
+		return nil, err
+	}
+	return e.GrexContext(ctx, m, limits)
+}
+
+//GrexContext builds the graph of the expression, using the Manager, within the limits. MaxLength does not apply.
+func (e *Expr) GrexContext(ctx context.Context, m Manager, limits Limits) (*Grex, error) {
+	if depth := e.depth(); limits.MaxDepth > 0 && depth > limits.MaxDepth {
+		return nil, &ErrLimitExceeded{"MaxDepth", limits.MaxDepth, depth}
+	}
+	return e.grexLimited(m, &limiter{ctx: ctx, limits: limits})
+}
+
+//depth is the nesting of the operators. (a, b), c is the same as a, (b, c), so a chain of "," or "|" counts once.
+func (e *Expr) depth() int {
+	depth := 0
+	for _, sub := range e.Subs {
+		d := sub.depth()
+		if sub.Op != e.Op || (e.Op != OpSeq && e.Op != OpSel) {
+			d++
+		}
+		if d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
+
+//grexLimited is Grex, but every vertex and edge is counted before it is created, copies included. A chain of "," or
+// "|" is built in one pass: folding it pair by pair would copy the graph built so far at every step.
+func (e *Expr) grexLimited(m Manager, l *limiter) (*Grex, error) {
+	switch e.Op {
+	case OpNothing, OpEmpty, OpSymbol:
+		if err := l.add(2, 1); err != nil { // at most a terminal
+			return nil, err
+		}
+		return e.Grex(m), nil
+	case OpStar, OpPlus, OpOpt:
+		g, err := e.Subs[0].grexLimited(m, l)
+		if err != nil {
+			return nil, err
+		}
+		return repeatLimited(g, e.Op, l)
+	}
+	var operands []*Grex
+	for _, sub := range e.operands(nil) {
+		g, err := sub.grexLimited(m, l)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, g)
+	}
+	if e.Op == OpSeq {
+		return seqLimited(operands, false, l)
+	}
+	return selLimited(operands, l)
+}
+
+//operands appends the operands of the chain of the same operator: (a, b), c is a, b, c
+func (e *Expr) operands(operands []*Expr) []*Expr {
+	for _, sub := range e.Subs {
+		if sub.Op == e.Op {
+			operands = sub.operands(operands)
+		} else {
+			operands = append(operands, sub)
+		}
+	}
+	return operands
+}
+
+//copyLimited is copyGraphInto, within the limits. Vertices already in m are not cloned but mapped to a vertex of
+// target, and the edges of a vertex mapped to nil are dropped.
+func (g *Grex) copyLimited(target *Grex, m map[Vertex]Vertex, l *limiter) (map[Vertex]Vertex, error) {
+	if err := l.add(len(g.graph.vertices), len(g.graph.edges)); err != nil {
+		return nil, err
+	}
+	for v := range g.graph.vertices {
+		if _, ok := m[v]; !ok {
+			m[v] = target.manager.NewVertex()
+			target.graph.AddVertex(m[v])
+		}
+	}
+	for t, b := range g.graph.edges {
+		if err := l.err(); err != nil {
+			return nil, err
+		}
+		start, end := m[b.start], m[b.end]
+		if start == nil || end == nil {
+			continue
+		}
+		target.addClone(t, g.spans, start, end)
+	}
+	return m, nil
+}
+
+//addClone adds a clone of the edge, and its span
+func (g *Grex) addClone(t Edge, spans map[Edge]Span, start, end Vertex) {
+	clone := g.manager.CloneEdge(t)
+	g.graph.AddEdge(clone, start, end)
+	if span, ok := spans[t]; ok {
+		g.spans[clone] = span
+	}
+}
+
+//mergeLimited is mergeOutbounds for several vertices at once: every vertex of outs gets a clone of the edges, mapped
+// by m. The edges are listed once, instead of scanning the whole graph for each vertex.
+func (g *Grex) mergeLimited(from *Grex, edges []Edge, outs map[Vertex]interface{}, m map[Vertex]Vertex, l *limiter) error {
+	if err := l.add(0, len(outs)*len(edges)); err != nil {
+		return err
+	}
+	for out := range outs {
+		if err := l.err(); err != nil {
+			return err
+		}
+		for _, t := range edges {
+			g.addClone(t, from.spans, out, m[from.graph.Dest(t)])
+		}
+	}
+	return nil
+}
+
+//repeatLimited returns g*, g+ or g?, with a single copy of g
+func repeatLimited(g *Grex, op Op, l *limiter) (*Grex, error) {
+	n := NewGrex(g.manager)
+	m, err := g.copyLimited(n, make(map[Vertex]Vertex), l)
+	if err != nil {
+		return nil, err
+	}
+	n.in = m[g.in]
+	for out := range g.outs {
+		n.outs[m[out]] = nil
+	}
+	if op != OpOpt { // every output can go on as the input does
+		outs := make(map[Vertex]interface{})
+		for out := range n.outs {
+			outs[out] = nil
+		}
+		if err := n.mergeLimited(g, g.graph.OutEdges(g.in), outs, m, l); err != nil {
+			return nil, err
+		}
+	}
+	if op != OpPlus {
+		n.outs[n.in] = nil
+	}
+	return n, nil
+}
+
+//seqLimited returns the sequence of the operands. The input of every operand but the first is not copied, its
+// outbounds are copied to the outputs of the sequence so far instead. When keep is true, the outputs of every operand
+// stay outputs: a, b, c becomes a, (b, c?)?
+func seqLimited(operands []*Grex, keep bool, l *limiter) (*Grex, error) {
+	n := NewGrex(operands[0].manager)
+	m, err := operands[0].copyLimited(n, make(map[Vertex]Vertex), l)
+	if err != nil {
+		return nil, err
+	}
+	n.in = m[operands[0].in]
+	outs := make(map[Vertex]interface{}) // the outputs of the sequence so far
+	for out := range operands[0].outs {
+		outs[m[out]] = nil
+		n.outs[m[out]] = nil
+	}
+	for _, that := range operands[1:] {
+		m, err := that.copyLimited(n, map[Vertex]Vertex{that.in: nil}, l)
+		if err != nil {
+			return nil, err
+		}
+		if err := n.mergeLimited(that, that.graph.OutEdges(that.in), outs, m, l); err != nil {
+			return nil, err
+		}
+		if _, io := that.outs[that.in]; !io { // the outputs so far go on only if that accepts the empty sequence
+			outs = make(map[Vertex]interface{})
+		}
+		for out := range that.outs {
+			if out != that.in {
+				outs[m[out]] = nil
+			}
+		}
+		if !keep {
+			n.outs = make(map[Vertex]interface{})
+		}
+		for out := range outs {
+			n.outs[out] = nil
+		}
+	}
+	return n, nil
+}
+
+//selLimited returns the alternation of the operands: they share a new input vertex.
+func selLimited(operands []*Grex, l *limiter) (*Grex, error) {
+	n := NewGrex(operands[0].manager)
+	if err := l.add(1, 0); err != nil {
+		return nil, err
+	}
+	n.in = n.manager.NewVertex()
+	n.graph.AddVertex(n.in)
+	for _, g := range operands {
+		m, err := g.copyLimited(n, map[Vertex]Vertex{g.in: n.in}, l)
+		if err != nil {
+			return nil, err
+		}
+		for out := range g.outs {
+			n.outs[m[out]] = nil
+		}
+	}
+	return n, nil
+}
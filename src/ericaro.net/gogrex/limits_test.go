@@ -0,0 +1,102 @@
+package gogrex
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGrexContext(t *testing.T) {
+	exceeded := map[string]Limits{
+		"MaxLength":   {MaxLength: 10},
+		"MaxDepth":    {MaxDepth: 3},
+		"MaxVertices": {MaxVertices: 5},
+		"MaxEdges":    {MaxEdges: 20},
+	}
+	exp := "((((a*, b)*, c)*, d)*, e)*"
+	for name, limits := range exceeded {
+		var m StringManager
+		_, err := ParseGrexContext(context.Background(), &m, exp, limits)
+		if lerr, ok := err.(*ErrLimitExceeded); !ok || lerr.Limit != name {
+			t.Errorf("%s: expected the limit to be exceeded, got %v", name, err)
+		}
+	}
+
+	// a chain of sequences is not nested
+	var m StringManager
+	long := strings.Repeat("a, ", 50) + "a"
+	if _, err := ParseGrexContext(context.Background(), &m, long, Limits{MaxDepth: 1}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// within the limits, the grex is the same
+	g, err := ParseGrexContext(context.Background(), &m, exp, Limits{MaxLength: 100, MaxDepth: 10, MaxVertices: 100, MaxEdges: 1000})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	expected, _ := ParseGrex(&m, exp)
+	if len(g.Vertices()) != len(expected.Vertices()) || len(g.Edges()) != len(expected.Edges()) {
+		t.Errorf("expected %d vertices and %d edges, got %d and %d", len(expected.Vertices()), len(expected.Edges()), len(g.Vertices()), len(g.Edges()))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ParseGrexContext(ctx, &m, exp, Limits{}); err != context.Canceled {
+		t.Errorf("expected the parsing to be canceled, got %v", err)
+	}
+}
+
+func TestGrexContext(t *testing.T) {
+	// the same language as Grex
+	for _, exp := range []string{"a, b, c", "a|b|c", "a?, b*, c?", "(a, b?)+, c", "(a|b?)*", "((a|b), c)?, (a*|c)+"} {
+		var m StringManager
+		e, _ := ParseExpr(exp)
+		g, err := e.GrexContext(context.Background(), &m, Limits{})
+		if err != nil {
+			t.Fatalf("%s: %v", exp, err)
+		}
+		expected := e.Grex(&m)
+		for _, s := range sequences([]string{"a", "b", "c"}, 4) {
+			if g.Match(s) != expected.Match(s) {
+				t.Errorf("%s on %v: expected %v", exp, s, expected.Match(s))
+			}
+		}
+	}
+
+	// the final graph is within the limits, but not the copies needed to build it
+	var b strings.Builder
+	b.WriteString("(b0")
+	for i := 1; i < 300; i++ {
+		fmt.Fprintf(&b, "|b%d", i)
+	}
+	b.WriteString(")+")
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&b, ", c%d", i)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var m StringManager
+	_, err := ParseGrexContext(ctx, &m, b.String(), Limits{MaxVertices: 10000, MaxEdges: 100000})
+	if _, ok := err.(*ErrLimitExceeded); !ok {
+		t.Errorf("expected the limit to be exceeded, got %v", err)
+	}
+}
+
+func TestDeterminizeContext(t *testing.T) {
+	var m StringManager
+	// the 9th symbol from the end is an a: 2^9 states
+	g, _ := ParseGrex(&m, "(a|b)*, a"+strings.Repeat(", (a|b)", 8))
+	_, err := g.DeterminizeContext(context.Background(), Limits{MaxVertices: 100})
+	if lerr, ok := err.(*ErrLimitExceeded); !ok || lerr.Limit != "MaxVertices" {
+		t.Errorf("expected too many states, got %v", err)
+	}
+	d, err := g.DeterminizeContext(context.Background(), Limits{MaxVertices: 1000, MaxEdges: 2000})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if got, expected := len(d.Vertices()), len(g.Determinize().Vertices()); got != expected {
+		t.Errorf("expected %d vertices, got %d", expected, got)
+	}
+}
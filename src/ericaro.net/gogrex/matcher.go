@@ -0,0 +1,94 @@
+package gogrex
+
+import (
+	"sort"
+)
+
+// A grex is not deterministic: a vertex can have several outbounds with the same name (think "a*,a").
+// Therefore matching keeps track of the set of vertices that can be reached by the symbols read so far.
+
+//SequenceMatcher reads a sequence of symbols one at a time. It is implemented by Matcher on a grex, and by
+// DerivativeMatcher directly on an expression.
+type SequenceMatcher interface {
+	//Next reads a symbol, and returns false if it is not expected. In that case the symbol is ignored.
+	Next(symbol string) bool
+	//Accepts tells if the symbols read so far are a complete sequence.
+	Accepts() bool
+	//Expected returns the sorted list of symbols that can be read next.
+	Expected() []string
+	//Reset goes back to the start, as if nothing had been read.
+	Reset()
+}
+
+//Matcher reads a sequence of symbols one at a time, and follows them in the grex.
+type Matcher struct {
+	grex    *Grex
+	current map[Vertex]interface{} // every vertex reachable by the symbols read so far
+}
+
+//NewMatcher creates a matcher positioned on the input vertex of the grex.
+func (g *Grex) NewMatcher() *Matcher {
+	m := &Matcher{grex: g}
+	m.Reset()
+	return m
+}
+
+//Reset goes back to the input vertex, as if nothing had been read.
+func (m *Matcher) Reset() {
+	m.current = map[Vertex]interface{}{m.grex.in: nil}
+}
+
+//Next reads a symbol, and returns false if it is not expected.
+// In that case the symbol is simply ignored, and the matcher can go on with the next one.
+func (m *Matcher) Next(symbol string) bool {
+	next := make(map[Vertex]interface{})
+	for v := range m.current {
+		for _, t := range m.grex.graph.OutEdges(v) {
+			if t.Name() == symbol {
+				next[m.grex.graph.Dest(t)] = nil
+			}
+		}
+	}
+	if len(next) == 0 {
+		return false
+	}
+	m.current = next
+	return true
+}
+
+//Accepts tells if the symbols read so far are a complete sequence.
+func (m *Matcher) Accepts() bool {
+	for v := range m.current {
+		if _, ok := m.grex.outs[v]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+//Expected returns the sorted list of symbols that can be read next.
+func (m *Matcher) Expected() []string {
+	names := make(map[string]interface{})
+	for v := range m.current {
+		for _, t := range m.grex.graph.OutEdges(v) {
+			names[t.Name()] = nil
+		}
+	}
+	expected := make([]string, 0, len(names))
+	for name := range names {
+		expected = append(expected, name)
+	}
+	sort.Strings(expected)
+	return expected
+}
+
+//Match tells if the whole sequence of symbols is accepted by the grex.
+func (g *Grex) Match(symbols []string) bool {
+	m := g.NewMatcher()
+	for _, s := range symbols {
+		if !m.Next(s) {
+			return false
+		}
+	}
+	return m.Accepts()
+}
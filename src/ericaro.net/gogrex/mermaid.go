@@ -0,0 +1,64 @@
+package gogrex
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Mermaid and PlantUML state diagrams. Vertices are states named s0, s1 ... in breadth first order from the input,
+// the input is reached from the start [*], and every output goes to the end [*]. Transitions are written in
+// sortedOutEdges order, so the same grex is always written the same way.
+
+//WriteMermaid writes the grex as a Mermaid stateDiagram-v2.
+func (g *Grex) WriteMermaid(w io.Writer) error {
+	return g.writeStateDiagram(w, "stateDiagram-v2\n", "", mermaidLabel)
+}
+
+//WritePlantUML writes the grex as a PlantUML state diagram.
+func (g *Grex) WritePlantUML(w io.Writer) error {
+	return g.writeStateDiagram(w, "@startuml\nhide empty description\n", "@enduml\n", plantUMLLabel)
+}
+
+//writeStateDiagram writes the transitions, in a syntax common to Mermaid and PlantUML
+func (g *Grex) writeStateDiagram(w io.Writer, header, footer string, label func(string) string) error {
+	vertices, out := g.orderedVertices()
+	index := make(map[Vertex]int)
+	for i, v := range vertices {
+		index[v] = i
+	}
+	e := &errWriter{w: w}
+	e.printf("%s", header)
+	e.printf("    [*] --> s%d\n", index[g.in])
+	for _, v := range vertices {
+		for _, t := range out[v] {
+			e.printf("    s%d --> s%d : %s\n", index[v], index[g.graph.Dest(t)], label(t.Name()))
+		}
+	}
+	for _, v := range vertices {
+		if _, ok := g.outs[v]; ok {
+			e.printf("    s%d --> [*]\n", index[v])
+		}
+	}
+	e.printf("%s", footer)
+	return e.err
+}
+
+//mermaidLabel escapes the characters that Mermaid would interpret, with its "#code;" entities
+func mermaidLabel(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_-. ", r) {
+			b.WriteRune(r)
+		} else {
+			fmt.Fprintf(&b, "#%d;", r)
+		}
+	}
+	return b.String()
+}
+
+//plantUMLLabel only needs to keep the label on a single line
+func plantUMLLabel(name string) string {
+	return strings.NewReplacer("\n", `\n`, "\r", `\r`).Replace(name)
+}
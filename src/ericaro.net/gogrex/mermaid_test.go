@@ -0,0 +1,67 @@
+package gogrex
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStateDiagrams(t *testing.T) {
+	var m StringManager
+	g, err := ParseGrex(&m, "a, (b|c)*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transitions := `    [*] --> s0
+    s0 --> s1 : a
+    s1 --> s2 : b
+    s1 --> s3 : c
+    s2 --> s2 : b
+    s2 --> s3 : c
+    s3 --> s2 : b
+    s3 --> s3 : c
+    s1 --> [*]
+    s2 --> [*]
+    s3 --> [*]
+`
+	var b bytes.Buffer
+	if err := g.WriteMermaid(&b); err != nil {
+		t.Fatal(err)
+	}
+	if want := "stateDiagram-v2\n" + transitions; b.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, b.String())
+	}
+	b.Reset()
+	if err := g.WritePlantUML(&b); err != nil {
+		t.Fatal(err)
+	}
+	if want := "@startuml\nhide empty description\n" + transitions + "@enduml\n"; b.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, b.String())
+	}
+	if got := mermaidLabel("a:b;c"); got != "a#58;b#59;c" {
+		t.Errorf("unexpected escaped label %q", got)
+	}
+}
+
+func TestStateDiagramAmbiguous(t *testing.T) {
+	// both a edges have the same name: the one that ends sooner, in name order, comes first
+	want := `stateDiagram-v2
+    [*] --> s0
+    s0 --> s1 : a
+    s0 --> s2 : a
+    s1 --> s3 : b
+    s2 --> s4 : c
+    s3 --> [*]
+    s4 --> [*]
+`
+	for i := 0; i < 100; i++ {
+		var m StringManager
+		g, _ := ParseGrex(&m, "(a,c)|(a,b)")
+		var b bytes.Buffer
+		if err := g.WriteMermaid(&b); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != want {
+			t.Fatalf("run %d: expected\n%s\ngot\n%s", i, want, b.String())
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package gogrex
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Dot files written by String (or WriteDot) can be read back. The input vertex is the node labelled "In", outputs
+// are labelled "Out", and "IO" is both. Every edge must have a label, that is the name of the edge.
+//
+// The reader accepts the usual dot syntax around it: graph, node and edge attribute statements are skipped,
+// subgraphs (and clusters) are flattened, comments are ignored. Node ids are only used to connect edges: vertices and
+// edges are created by the Manager.
+
+//ReadDot reads a directed graph in dot format, and builds a Grex using the Manager.
+func ReadDot(m Manager, r io.Reader) (*Grex, error) {
+	input, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	d := &dotReader{input: string(input), grex: NewGrex(m), vertices: make(map[string]Vertex)}
+	if err := d.graph(); err != nil {
+		return nil, err
+	}
+	if !d.hasIn {
+		return nil, errors.New("dot: no node labelled In or IO")
+	}
+	return d.grex, nil
+}
+
+//dotReader is a recursive descent parser of the dot language
+type dotReader struct {
+	input     string
+	pos       int
+	tok       string // current token, "" at the end of the input
+	quoted    bool   // the current token is a quoted string
+	grex      *Grex
+	vertices  map[string]Vertex // by node id
+	mentioned []string          // node ids used in the current subgraph
+	hasIn     bool
+}
+
+//next reads the next token: an id, a quoted string (unquoted), "->", "--" or a single punctuation character
+func (d *dotReader) next() error {
+	d.quoted = false
+	// skip spaces and comments
+	for d.pos < len(d.input) {
+		rest := d.input[d.pos:]
+		switch {
+		case unicode.IsSpace(rune(rest[0])):
+			d.pos++
+		case strings.HasPrefix(rest, "//") || rest[0] == '#':
+			if i := strings.IndexByte(rest, '\n'); i >= 0 {
+				d.pos += i
+			} else {
+				d.pos = len(d.input)
+			}
+		case strings.HasPrefix(rest, "/*"):
+			i := strings.Index(rest, "*/")
+			if i < 0 {
+				return d.errorf("unterminated comment")
+			}
+			d.pos += i + 2
+		default:
+			goto token
+		}
+	}
+token:
+	if d.pos >= len(d.input) {
+		d.tok = ""
+		return nil
+	}
+	rest := d.input[d.pos:]
+	switch c := rest[0]; {
+	case c == '"':
+		for i := 1; i < len(rest); i++ {
+			switch rest[i] {
+			case '\\':
+				i++ // the escaped character cannot end the string
+			case '"':
+				d.pos += i + 1
+				d.tok, d.quoted = unquote(rest[:i+1]), true
+				return nil
+			}
+		}
+		return d.errorf("unterminated string")
+	case strings.HasPrefix(rest, "->") || strings.HasPrefix(rest, "--"):
+		d.tok = rest[:2]
+	case strings.IndexByte("{}[];,=:", c) >= 0:
+		d.tok = rest[:1]
+	default:
+		i := strings.IndexFunc(rest, func(r rune) bool {
+			return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.')
+		})
+		if i == 0 {
+			return d.errorf("unexpected %q", rest[0])
+		}
+		if i < 0 {
+			i = len(rest)
+		}
+		d.tok = rest[:i]
+	}
+	d.pos += len(d.tok)
+	return nil
+}
+
+//unquote decodes a quoted string. Labels are written with Go escapes, other strings only have DOT's escaped quote, and
+// keep their other backslashes, like \l in labels.
+func unquote(quoted string) string {
+	if s, err := strconv.Unquote(quoted); err == nil {
+		return s
+	}
+	return strings.Replace(quoted[1:len(quoted)-1], `\"`, `"`, -1)
+}
+
+//isID tells if the current token is an identifier
+func (d *dotReader) isID() bool {
+	return d.quoted || d.tok != "" && strings.IndexByte("{}[];,=:-", d.tok[0]) < 0
+}
+
+//expect checks the current token, and reads the next one
+func (d *dotReader) expect(tok string) error {
+	if d.tok != tok || d.quoted {
+		return d.errorf("expected %q, found %q", tok, d.tok)
+	}
+	return d.next()
+}
+
+//graph reads: [strict] digraph [id] { statements }
+func (d *dotReader) graph() error {
+	if err := d.next(); err != nil {
+		return err
+	}
+	if strings.EqualFold(d.tok, "strict") {
+		if err := d.next(); err != nil {
+			return err
+		}
+	}
+	if !strings.EqualFold(d.tok, "digraph") {
+		return d.errorf("expected digraph, found %q", d.tok)
+	}
+	if err := d.next(); err != nil {
+		return err
+	}
+	if d.isID() {
+		if err := d.next(); err != nil {
+			return err
+		}
+	}
+	if err := d.block(); err != nil {
+		return err
+	}
+	if d.tok != "" {
+		return d.errorf("unexpected %q after the graph", d.tok)
+	}
+	return nil
+}
+
+//block reads: { statements }
+func (d *dotReader) block() error {
+	if err := d.expect("{"); err != nil {
+		return err
+	}
+	for d.tok != "}" || d.quoted {
+		if d.tok == "" {
+			return d.errorf("missing '}'")
+		}
+		if err := d.statement(); err != nil {
+			return err
+		}
+		if d.tok == ";" || d.tok == "," {
+			if err := d.next(); err != nil {
+				return err
+			}
+		}
+	}
+	return d.next()
+}
+
+//statement reads an attribute statement, a subgraph, a node or an edge statement
+func (d *dotReader) statement() error {
+	if !d.quoted {
+		switch strings.ToLower(d.tok) {
+		case "graph", "node", "edge":
+			if err := d.next(); err != nil {
+				return err
+			}
+			_, err := d.attributes()
+			return err
+		}
+	}
+	subgraph := !d.quoted && (d.tok == "{" || strings.EqualFold(d.tok, "subgraph"))
+	from, err := d.endpoint()
+	if err != nil {
+		return err
+	}
+	if d.tok == "=" && !d.quoted { // graph attribute
+		if err := d.next(); err != nil {
+			return err
+		}
+		if !d.isID() {
+			return d.errorf("expected a value, found %q", d.tok)
+		}
+		return d.next()
+	}
+	if d.tok == "--" {
+		return d.errorf("undirected edges are not supported")
+	}
+	if d.tok != "->" || d.quoted {
+		if subgraph {
+			return nil
+		}
+		attrs, err := d.attributes()
+		if err != nil {
+			return err
+		}
+		return d.node(from[0], attrs)
+	}
+	// edge chain
+	chain := [][]string{from}
+	for d.tok == "->" && !d.quoted {
+		if err := d.next(); err != nil {
+			return err
+		}
+		to, err := d.endpoint()
+		if err != nil {
+			return err
+		}
+		chain = append(chain, to)
+	}
+	attrs, err := d.attributes()
+	if err != nil {
+		return err
+	}
+	name, ok := attrs["label"]
+	if !ok {
+		return d.errorf("edge without label")
+	}
+	for i := 1; i < len(chain); i++ {
+		for _, a := range chain[i-1] {
+			for _, b := range chain[i] {
+				d.grex.graph.AddEdge(d.grex.manager.NewEdge(name), d.vertex(a), d.vertex(b))
+			}
+		}
+	}
+	return nil
+}
+
+//endpoint reads a node id (with an optional port), or a subgraph. It returns the node ids.
+func (d *dotReader) endpoint() ([]string, error) {
+	if d.quoted || !strings.EqualFold(d.tok, "subgraph") && d.tok != "{" {
+		if !d.isID() {
+			return nil, d.errorf("unexpected %q", d.tok)
+		}
+		id := d.tok
+		if err := d.next(); err != nil {
+			return nil, err
+		}
+		// ports are ignored: id:port[:compass]
+		for d.tok == ":" && !d.quoted {
+			if err := d.next(); err != nil {
+				return nil, err
+			}
+			if err := d.next(); err != nil {
+				return nil, err
+			}
+		}
+		return []string{id}, nil
+	}
+	if d.tok != "{" {
+		if err := d.next(); err != nil {
+			return nil, err
+		}
+		if d.isID() {
+			if err := d.next(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// remember the nodes used inside the subgraph
+	outer := d.mentioned
+	d.mentioned = nil
+	if err := d.block(); err != nil {
+		return nil, err
+	}
+	inner := d.mentioned
+	d.mentioned = append(outer, inner...)
+	var ids []string
+	seen := make(map[string]bool)
+	for _, id := range inner {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+//attributes reads any number of attribute lists: [a=b, c=d; e] [ ... ]
+func (d *dotReader) attributes() (map[string]string, error) {
+	attrs := make(map[string]string)
+	for d.tok == "[" && !d.quoted {
+		if err := d.next(); err != nil {
+			return nil, err
+		}
+		for d.tok != "]" || d.quoted {
+			if !d.isID() {
+				return nil, d.errorf("expected an attribute, found %q", d.tok)
+			}
+			key := d.tok
+			if err := d.next(); err != nil {
+				return nil, err
+			}
+			if d.tok == "=" && !d.quoted {
+				if err := d.next(); err != nil {
+					return nil, err
+				}
+				if !d.isID() {
+					return nil, d.errorf("expected a value for %s, found %q", key, d.tok)
+				}
+				attrs[key] = d.tok
+				if err := d.next(); err != nil {
+					return nil, err
+				}
+			}
+			if (d.tok == "," || d.tok == ";") && !d.quoted {
+				if err := d.next(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := d.next(); err != nil {
+			return nil, err
+		}
+	}
+	return attrs, nil
+}
+
+//node declares a node, the input or an output if it is labelled In, Out or IO (possibly followed by its id)
+func (d *dotReader) node(id string, attrs map[string]string) error {
+	v := d.vertex(id)
+	var kind string
+	if fields := strings.Fields(attrs["label"]); len(fields) > 0 {
+		kind = fields[0]
+	}
+	switch kind {
+	case "In", "IO":
+		if d.hasIn && d.grex.in != v {
+			return d.errorf("several nodes are labelled In")
+		}
+		d.grex.in, d.hasIn = v, true
+	}
+	switch kind {
+	case "Out", "IO":
+		d.grex.outs[v] = nil
+	}
+	return nil
+}
+
+//vertex returns the vertex of a node id, created on first use
+func (d *dotReader) vertex(id string) Vertex {
+	d.mentioned = append(d.mentioned, id)
+	v, ok := d.vertices[id]
+	if !ok {
+		v = d.grex.manager.NewVertex()
+		d.vertices[id] = v
+		d.grex.graph.AddVertex(v)
+	}
+	return v
+}
+
+//errorf returns an error prefixed by the current line number.
+func (d *dotReader) errorf(format string, args ...interface{}) error {
+	line := strings.Count(d.input[:d.pos], "\n") + 1
+	return errors.New(fmt.Sprintf("dot:%d: %s", line, fmt.Sprintf(format, args...)))
+}
@@ -0,0 +1,91 @@
+package gogrex
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadDot(t *testing.T) {
+	var m StringManager
+	for _, exp := range []string{"a", "a, (b|c)*, d", "(a|b)?", "a*, b+"} {
+		g, err := ParseGrex(&m, exp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := ReadDot(&m, strings.NewReader(g.String()))
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", exp, err, g.String())
+		}
+		a, _ := g.MarshalJSON()
+		b, _ := r.MarshalJSON()
+		if !bytes.Equal(stripSpans(a), stripSpans(b)) {
+			t.Errorf("%s: round trip failed\n%s\n%s", exp, a, b)
+		}
+	}
+
+	// styled output, with clusters and labels
+	e, _ := ParseExpr("a, (b, c)*")
+	g := e.Grex(&m)
+	var w bytes.Buffer
+	g.WriteDot(&w, &DotOptions{VertexLabels: true, Clusters: g.SubexpressionClusters(e), HighlightVertices: []Vertex{g.in}})
+	r, err := ReadDot(&m, &w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Match([]string{"a", "b", "c"}) || r.Match([]string{"a", "b"}) {
+		t.Errorf("unexpected grex read from styled dot\n%s", r)
+	}
+
+	// hand written
+	r, err = ReadDot(&m, strings.NewReader(`/* edited */ digraph G {
+		graph [rankdir=TB]; node [shape=circle]
+		start [label="In"] end [label=Out]
+		subgraph cluster_x { label="loop"; x }
+		start -> x [label="a"]
+		x -> x [label="b"] // a loop
+		x -> end [label="c"]
+		start -> {end x} [label="d"]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Match([]string{"a", "b", "b", "c"}) || !r.Match([]string{"d"}) || !r.Match([]string{"d", "c"}) {
+		t.Errorf("unexpected grex read from hand written dot\n%s", r)
+	}
+
+	// escaped names
+	names := []string{`a\b`, `say "hi"`, "tab\there", "new\nline", `\n`, "\u00a0", "é", `\"`}
+	g = Terminal(&m, names[0])
+	for _, name := range names[1:] {
+		g = Sel(g, Terminal(&m, name))
+	}
+	r, err = ReadDot(&m, strings.NewReader(g.String()))
+	if err != nil {
+		t.Fatalf("escaped names: %v\n%s", err, g)
+	}
+	for _, name := range names {
+		if !r.Match([]string{name}) {
+			t.Errorf("%q was not read back\n%s", name, g)
+		}
+	}
+
+	for _, bad := range []string{"graph { a -- b }", "digraph { a -> b }", `digraph { a [label="In"]; a -> b [color=red] }`, `digraph { a [label="In"]`} {
+		if _, err := ReadDot(&m, strings.NewReader(bad)); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+	}
+}
+
+//stripSpans removes the spans of a JSON encoded grex
+func stripSpans(data []byte) []byte {
+	var b bytes.Buffer
+	for {
+		i := bytes.Index(data, []byte(`,"span":`))
+		if i < 0 {
+			return append(b.Bytes(), data...)
+		}
+		b.Write(data[:i])
+		data = data[i+bytes.IndexByte(data[i:], '}')+1:]
+	}
+}
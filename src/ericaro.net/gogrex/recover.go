@@ -0,0 +1,146 @@
+package gogrex
+
+import (
+	"fmt"
+)
+
+// Recovering from errors means finding the accepted sequence that is the closest to the input: the one that needs the
+// minimum number of edits (insertions, deletions, substitutions) to be reached. Every edit is an error to report, and
+// matching goes on after each of them, so a single missing symbol does not hide the errors that follow.
+//
+// The search runs over pairs (position in the input, vertex in the grex). Following an edge with the next input
+// symbol costs nothing, every edit costs one. It's a shortest path with 0/1 weights: a breadth first search over a
+// double ended queue.
+
+//EditKind is the kind of a suggested edit
+type EditKind int
+
+const (
+	Insert     EditKind = iota // a symbol is missing
+	Delete                     // a symbol should not be there
+	Substitute                 // a symbol should be replaced by another
+)
+
+//Suggestion is an edit that fixes the input sequence. Positions are indexes in the input sequence.
+type Suggestion struct {
+	Kind   EditKind
+	Pos    int    // the position of the edited symbol, for Insert the position before which to insert
+	Symbol string // the symbol to insert, or the replacement
+	Found  string // the symbol to delete or to replace
+}
+
+func (s Suggestion) String() string {
+	switch s.Kind {
+	case Insert:
+		return fmt.Sprintf("insert `%s` before position %d", s.Symbol, s.Pos)
+	case Delete:
+		return fmt.Sprintf("delete `%s` at position %d", s.Found, s.Pos)
+	}
+	return fmt.Sprintf("replace `%s` by `%s` at position %d", s.Found, s.Symbol, s.Pos)
+}
+
+//step is how a state of the search was reached
+type step struct {
+	from  int // previous state
+	edit  bool
+	kind  EditKind
+	label string // symbol inserted or substituted
+}
+
+//Recover matches the symbols, and returns the minimum list of edits that make them accepted, in input order.
+// The list is empty if the symbols are accepted as is. ok is false if the grex accepts no sequence at all.
+func (g *Grex) Recover(symbols []string) (suggestions []Suggestion, ok bool) {
+	vertices := g.graph.sortedVertices()
+	out, _ := g.sortedOutEdges()
+	index := make(map[Vertex]int)
+	for i, v := range vertices {
+		index[v] = i
+	}
+	n, nv := len(symbols), len(vertices)
+	state := func(pos, v int) int { return pos*nv + v }
+
+	dist := make([]int, (n+1)*nv)
+	for i := range dist {
+		dist[i] = -1
+	}
+	steps := make([]step, len(dist))
+	done := make([]bool, len(dist))
+
+	start := state(0, index[g.in])
+	dist[start] = 0
+	steps[start].from = -1
+	// the deque is made of a stack of free states in front, and a queue of states that cost one more
+	front, back := []int{start}, []int{}
+	relax := func(from, to, cost int, s step) {
+		if dist[to] >= 0 && dist[to] <= dist[from]+cost {
+			return
+		}
+		dist[to] = dist[from] + cost
+		s.from = from
+		steps[to] = s
+		if cost == 0 {
+			front = append(front, to)
+		} else {
+			back = append(back, to)
+		}
+	}
+
+	goal := -1
+	for len(front) > 0 || len(back) > 0 {
+		var current int
+		if len(front) > 0 {
+			current, front = front[len(front)-1], front[:len(front)-1]
+		} else {
+			current, back = back[0], back[1:]
+		}
+		if done[current] {
+			continue
+		}
+		done[current] = true
+		pos, v := current/nv, vertices[current%nv]
+		if _, out := g.outs[v]; out && pos == n {
+			goal = current
+			break
+		}
+		edges := out[v]
+		for _, t := range edges {
+			w := index[g.graph.Dest(t)]
+			if pos < n && t.Name() == symbols[pos] {
+				relax(current, state(pos+1, w), 0, step{})
+			}
+		}
+		for _, t := range edges {
+			relax(current, state(pos, index[g.graph.Dest(t)]), 1, step{edit: true, kind: Insert, label: t.Name()})
+		}
+		if pos < n {
+			for _, t := range edges {
+				if t.Name() != symbols[pos] {
+					relax(current, state(pos+1, index[g.graph.Dest(t)]), 1, step{edit: true, kind: Substitute, label: t.Name()})
+				}
+			}
+			relax(current, state(pos+1, current%nv), 1, step{edit: true, kind: Delete})
+		}
+	}
+	if goal < 0 {
+		return nil, false
+	}
+
+	// walk back to the start, collecting the edits
+	suggestions = []Suggestion{}
+	for s := goal; steps[s].from >= 0; s = steps[s].from {
+		st := steps[s]
+		if !st.edit {
+			continue
+		}
+		pos := st.from / nv
+		sug := Suggestion{Kind: st.kind, Pos: pos, Symbol: st.label}
+		if st.kind != Insert {
+			sug.Found = symbols[pos]
+		}
+		suggestions = append(suggestions, sug)
+	}
+	for i, j := 0, len(suggestions)-1; i < j; i, j = i+1, j-1 {
+		suggestions[i], suggestions[j] = suggestions[j], suggestions[i]
+	}
+	return suggestions, true
+}
@@ -0,0 +1,41 @@
+package gogrex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	var m StringManager
+	g, err := ParseGrex(&m, "(timing, (id,value)+ )*, startDefinition, (id,name)*, endDefinition")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		input string
+		want  []string
+	}{
+		{"startDefinition endDefinition", nil},
+		{"timing id value startDefinition id name", []string{"insert `endDefinition` before position 6"}},
+		{"timing id startDefinition id name id name", []string{
+			"insert `value` before position 2",
+			"insert `endDefinition` before position 7",
+		}},
+		{"timing id value value startDefinition endDefinition", []string{"delete `value` at position 3"}},
+		{"timing id oops startDefinition endDefinition", []string{"replace `oops` by `value` at position 2"}},
+	}
+	for _, c := range cases {
+		suggestions, ok := g.Recover(strings.Fields(c.input))
+		if !ok {
+			t.Fatalf("%s: the grex accepts sequences", c.input)
+		}
+		if len(suggestions) != len(c.want) {
+			t.Fatalf("%s: expected %v got %v", c.input, c.want, suggestions)
+		}
+		for i, s := range suggestions {
+			if s.String() != c.want[i] {
+				t.Errorf("%s: expected %q got %q", c.input, c.want[i], s)
+			}
+		}
+	}
+}
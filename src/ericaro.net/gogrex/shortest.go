@@ -0,0 +1,225 @@
+package gogrex
+
+import (
+	"sort"
+)
+
+// Shortest sequences are found by a breadth first search over OutEdges. Edges are visited in a total order, so that
+// the same sequence is returned every time: by name, then by the first shortest sequence their target accepts, then by
+// span. Vertices, and edges, are created in map order, so they cannot tell apart two edges with the same name.
+
+//sortedOutEdges returns the sorted outbounds of every vertex, and the rank of every vertex by its first shortest
+// accepted sequence.
+func (g *Grex) sortedOutEdges() (out map[Vertex][]Edge, rank map[Vertex]int) {
+	out = make(map[Vertex][]Edge)
+	in := make(map[Vertex][]Edge)
+	for t, b := range g.graph.edges {
+		out[b.start] = append(out[b.start], t)
+		in[b.end] = append(in[b.end], t)
+	}
+	rank = g.suffixRanks(out, in)
+	for _, edges := range out {
+		sort.Slice(edges, func(i, j int) bool { return g.edgeLess(edges[i], edges[j], rank) })
+	}
+	return out, rank
+}
+
+//edgeLess orders edges by name, rank of their target, span, and as a last resort by their printed target
+func (g *Grex) edgeLess(a, b Edge, rank map[Vertex]int) bool {
+	if a.Name() != b.Name() {
+		return a.Name() < b.Name()
+	}
+	da, db := g.graph.Dest(a), g.graph.Dest(b)
+	if rank[da] != rank[db] {
+		return rank[da] < rank[db]
+	}
+	sa, oka := g.spans[a]
+	sb, okb := g.spans[b]
+	if oka != okb {
+		return okb // edges without span first
+	}
+	if sa != sb {
+		return sa.Start < sb.Start || sa.Start == sb.Start && sa.End < sb.End
+	}
+	return vertexLess(da, db)
+}
+
+//suffixRanks ranks the vertices by the first, in name order, of the shortest sequences they accept. Vertices closer to
+// an output come first, vertices that accept the same first sequence are tied, and those that accept nothing come last.
+func (g *Grex) suffixRanks(out, in map[Vertex][]Edge) map[Vertex]int {
+	rank := make(map[Vertex]int)
+	var layer []Vertex
+	for v := range g.outs {
+		rank[v] = 0 // the empty sequence
+		layer = append(layer, v)
+	}
+	next := 1
+	for len(layer) > 0 {
+		previous := make(map[Vertex]bool)
+		for _, v := range layer {
+			previous[v] = true
+		}
+		var further []Vertex // one step further from the outputs
+		for _, v := range layer {
+			for _, t := range in[v] {
+				s := g.graph.Source(t)
+				if _, ok := rank[s]; !ok {
+					rank[s] = -1 // found, not ranked yet
+					further = append(further, s)
+				}
+			}
+		}
+		// the first sequence of a vertex starts with its smallest edge to the previous layer
+		type key struct {
+			name string
+			rank int
+		}
+		first := make(map[Vertex]key)
+		for _, v := range further {
+			for _, t := range out[v] {
+				dest := g.graph.Dest(t)
+				if !previous[dest] {
+					continue
+				}
+				k, ok := first[v]
+				if !ok || t.Name() < k.name || t.Name() == k.name && rank[dest] < k.rank {
+					first[v] = key{t.Name(), rank[dest]}
+				}
+			}
+		}
+		sort.Slice(further, func(i, j int) bool {
+			a, b := first[further[i]], first[further[j]]
+			return a.name < b.name || a.name == b.name && a.rank < b.rank
+		})
+		for i, v := range further {
+			if i > 0 && first[v] != first[further[i-1]] {
+				next++
+			}
+			rank[v] = next
+		}
+		next++
+		layer = further
+	}
+	for v := range g.graph.vertices {
+		if _, ok := rank[v]; !ok {
+			rank[v] = next
+		}
+	}
+	return rank
+}
+
+//shortestPath runs a breadth first search from every vertex in sources, until a vertex in targets is reached.
+// It returns the edges of the path, and false if no target can be reached.
+func (g *Grex) shortestPath(sources []Vertex, targets map[Vertex]interface{}) ([]Edge, bool) {
+	out, rank := g.sortedOutEdges()
+	// start from the vertices in a stable order
+	sort.Slice(sources, func(i, j int) bool {
+		a, b := sources[i], sources[j]
+		return rank[a] < rank[b] || rank[a] == rank[b] && vertexLess(a, b)
+	})
+	parent := make(map[Vertex]Edge) // the edge used to reach a vertex, nil for sources
+	var queue []Vertex
+	for _, s := range sources {
+		if _, seen := parent[s]; !seen {
+			parent[s] = nil
+			queue = append(queue, s)
+		}
+	}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		if _, ok := targets[v]; ok {
+			// walk back to the source
+			path := []Edge{} // empty, not nil, when a source is a target
+			for t := parent[v]; t != nil; t = parent[g.graph.Source(t)] {
+				path = append(path, t)
+			}
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return path, true
+		}
+		for _, t := range out[v] {
+			dest := g.graph.Dest(t)
+			if _, seen := parent[dest]; !seen {
+				parent[dest] = t
+				queue = append(queue, dest)
+			}
+		}
+	}
+	return nil, false
+}
+
+//orderedVertices returns every vertex in breadth first order from the input, following edges in sortedOutEdges order,
+// and the sorted outbounds. Unreachable vertices come last, by rank. The order only depends on the structure of the
+// grex, not on the vertices themselves.
+func (g *Grex) orderedVertices() ([]Vertex, map[Vertex][]Edge) {
+	out, rank := g.sortedOutEdges()
+	vertices := []Vertex{g.in}
+	seen := map[Vertex]bool{g.in: true}
+	for i := 0; i < len(vertices); i++ {
+		for _, t := range out[vertices[i]] {
+			if dest := g.graph.Dest(t); !seen[dest] {
+				seen[dest] = true
+				vertices = append(vertices, dest)
+			}
+		}
+	}
+	var unreachable []Vertex
+	for _, v := range g.graph.sortedVertices() {
+		if !seen[v] {
+			unreachable = append(unreachable, v)
+		}
+	}
+	sort.SliceStable(unreachable, func(i, j int) bool { return rank[unreachable[i]] < rank[unreachable[j]] })
+	return append(vertices, unreachable...), out
+}
+
+//names returns the names of the edges
+func names(edges []Edge) []string {
+	names := make([]string, len(edges))
+	for i, t := range edges {
+		names[i] = t.Name()
+	}
+	return names
+}
+
+//ShortestPath returns the edges of a shortest path from one vertex to another, nil if there is none.
+// The path from a vertex to itself is empty.
+func (g *Grex) ShortestPath(from, to Vertex) []Edge {
+	path, ok := g.shortestPath([]Vertex{from}, map[Vertex]interface{}{to: nil})
+	if !ok {
+		return nil
+	}
+	return path
+}
+
+//ShortestAccepted returns a shortest sequence accepted by the grex, nil if it accepts nothing at all.
+// The result is empty, but not nil, if the grex accepts the empty sequence.
+func (g *Grex) ShortestAccepted() []string {
+	path, ok := g.shortestPath([]Vertex{g.in}, g.outs)
+	if !ok {
+		return nil
+	}
+	return names(path)
+}
+
+//Completions returns a shortest sequence that, appended to the prefix, makes it accepted.
+// It is empty if the prefix is already accepted, and nil if the prefix cannot be completed.
+func (g *Grex) Completions(prefix []string) []string {
+	m := g.NewMatcher()
+	for _, s := range prefix {
+		if !m.Next(s) {
+			return nil
+		}
+	}
+	var sources []Vertex
+	for v := range m.current {
+		sources = append(sources, v)
+	}
+	path, ok := g.shortestPath(sources, g.outs)
+	if !ok {
+		return nil
+	}
+	return names(path)
+}
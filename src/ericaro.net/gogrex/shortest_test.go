@@ -0,0 +1,61 @@
+package gogrex
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShortest(t *testing.T) {
+	var m StringManager
+	g, err := ParseGrex(&m, "(timing, (id,value)+ )*, startDefinition, (id,name)*, endDefinition")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := g.ShortestAccepted(), []string{"startDefinition", "endDefinition"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("shortest accepted: expected %v got %v", want, got)
+	}
+	completions := []struct {
+		prefix []string
+		want   []string
+	}{
+		{[]string{"timing"}, []string{"id", "value", "startDefinition", "endDefinition"}},
+		{[]string{"startDefinition", "id"}, []string{"name", "endDefinition"}},
+		{[]string{"startDefinition", "endDefinition"}, []string{}},
+		{[]string{"endDefinition"}, nil},
+	}
+	for _, c := range completions {
+		if got := g.Completions(c.prefix); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("completions of %v: expected %#v got %#v", c.prefix, c.want, got)
+		}
+	}
+
+	path := g.ShortestPath(g.InputVertex(), g.VertexByPath("timing.id.value"))
+	if got, want := names(path), []string{"timing", "id", "value"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("shortest path: expected %v got %v", want, got)
+	}
+	if path := g.ShortestPath(g.VertexByPath("startDefinition"), g.InputVertex()); path != nil {
+		t.Errorf("there is no way back to the input, got %v", names(path))
+	}
+	if path := g.ShortestPath(g.InputVertex(), g.InputVertex()); path == nil || len(path) != 0 {
+		t.Errorf("the path from a vertex to itself is empty, got %#v", path)
+	}
+
+	opt, _ := ParseGrex(&m, "a?")
+	if got := opt.ShortestAccepted(); got == nil || len(got) != 0 {
+		t.Errorf("a? accepts the empty sequence, got %#v", got)
+	}
+}
+
+func TestShortestStable(t *testing.T) {
+	// the two paths only differ by their last symbol: the smallest one is chosen
+	for i := 0; i < 100; i++ {
+		var m StringManager
+		g, _ := ParseGrex(&m, "(a,c)|(a,b)|(a,d,e)")
+		if got, want := g.ShortestAccepted(), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("run %d: expected %v got %v", i, want, got)
+		}
+		if got, want := g.Completions([]string{"a"}), []string{"b"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("run %d: expected %v got %v", i, want, got)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package gogrex
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// SVG drawing of the layered layout. Like the dot output, vertices are points, except the input and outputs that are
+// boxes labelled In, Out (or IO if both). Parallel edges are bent apart, and self loops are drawn above their vertex.
+
+const (
+	svgPoint     = 4.0  // radius of a point
+	svgBoxWidth  = 36.0 // size of the In/Out boxes
+	svgBoxHeight = 22.0
+	svgBend      = 16.0 // distance between parallel edges
+)
+
+//svgPos is a point of the drawing
+type svgPos struct {
+	x, y float64
+}
+
+//WriteSVG draws the grex as an SVG image. The layout is computed in pure Go, Graphviz is not needed.
+func (g *Grex) WriteSVG(w io.Writer) error {
+	l := g.layout()
+	e := &errWriter{w: w}
+	e.printf(`<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" font-family="sans-serif" font-size="12">
+  <defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="7" markerHeight="7" orient="auto"><path d="M0,0 L10,5 L0,10 z"/></marker></defs>
+`, l.width, l.height, l.width, l.height)
+
+	kind := func(n layoutNode) string {
+		if n.vertex == nil {
+			return ""
+		}
+		_, out := g.outs[n.vertex]
+		switch {
+		case n.vertex == g.in && out:
+			return "IO"
+		case n.vertex == g.in:
+			return "In"
+		case out:
+			return "Out"
+		}
+		return ""
+	}
+	// clip moves p to the border of node n, in the direction of 'toward'
+	clip := func(n int, toward svgPos) svgPos {
+		node := l.nodes[n]
+		p := svgPos{node.x, node.y}
+		if node.vertex == nil {
+			return p
+		}
+		hw, hh := svgPoint, svgPoint
+		if kind(node) != "" {
+			hw, hh = svgBoxWidth/2, svgBoxHeight/2
+		}
+		dx, dy := toward.x-p.x, toward.y-p.y
+		scale := math.Inf(1)
+		if dx != 0 {
+			scale = hw / math.Abs(dx)
+		}
+		if dy != 0 {
+			scale = math.Min(scale, hh/math.Abs(dy))
+		}
+		if math.IsInf(scale, 1) || scale > 1 {
+			return p
+		}
+		return svgPos{p.x + dx*scale, p.y + dy*scale}
+	}
+
+	// parallel edges between the same two nodes, whatever their direction
+	type pair struct{ a, b int }
+	key := func(e layoutEdge) pair {
+		a, b := e.nodes[0], e.nodes[len(e.nodes)-1]
+		if a > b {
+			a, b = b, a
+		}
+		return pair{a, b}
+	}
+	parallels := make(map[pair]int)
+	for _, t := range l.edges {
+		if len(t.nodes) == 2 {
+			parallels[key(t)]++
+		}
+	}
+	seen := make(map[pair]int)
+
+	e.printf("  <g fill=\"none\" stroke=\"black\">\n")
+	// labels are drawn last, over the edges and nodes
+	var labels []string
+	label := func(p svgPos, name string) {
+		labels = append(labels, fmt.Sprintf(`  <text x="%.1f" y="%.1f" text-anchor="middle" stroke="white" stroke-width="3" paint-order="stroke">%s</text>`+"\n", p.x, p.y, escape(name)))
+	}
+	for _, t := range l.edges {
+		switch len(t.nodes) {
+		case 1: // self loop, above the vertex
+			n := l.nodes[t.nodes[0]]
+			top := n.y - svgPoint
+			if kind(n) != "" {
+				top = n.y - svgBoxHeight/2
+			}
+			e.printf(`    <path d="M%.1f,%.1f C%.1f,%.1f %.1f,%.1f %.1f,%.1f" marker-end="url(#arrow)"/>`+"\n",
+				n.x-6, top, n.x-22, top-30, n.x+22, top-30, n.x+6, top)
+			label(svgPos{n.x, top - 26}, t.edge.Name())
+		case 2: // a quadratic curve, bent if there are parallel edges
+			k := key(t)
+			a, b := l.nodes[k.a], l.nodes[k.b]
+			offset := (float64(seen[k]) - float64(parallels[k]-1)/2) * svgBend
+			seen[k]++
+			dx, dy := b.x-a.x, b.y-a.y
+			length := math.Hypot(dx, dy)
+			c := svgPos{(a.x+b.x)/2 - dy/length*offset*2, (a.y+b.y)/2 + dx/length*offset*2}
+			from, to := t.nodes[0], t.nodes[1]
+			if t.reversed {
+				from, to = to, from
+			}
+			p0, p1 := clip(from, c), clip(to, c)
+			e.printf(`    <path d="M%.1f,%.1f Q%.1f,%.1f %.1f,%.1f" marker-end="url(#arrow)"/>`+"\n", p0.x, p0.y, c.x, c.y, p1.x, p1.y)
+			label(svgPos{(p0.x + 2*c.x + p1.x) / 4, (p0.y+2*c.y+p1.y)/4 - 4}, t.edge.Name())
+		default: // a polyline through the dummy nodes
+			nodes := append([]int(nil), t.nodes...)
+			if t.reversed {
+				for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+					nodes[i], nodes[j] = nodes[j], nodes[i]
+				}
+			}
+			points := make([]svgPos, len(nodes))
+			for i, n := range nodes {
+				points[i] = svgPos{l.nodes[n].x, l.nodes[n].y}
+			}
+			points[0] = clip(nodes[0], points[1])
+			points[len(points)-1] = clip(nodes[len(nodes)-1], points[len(points)-2])
+			e.printf(`    <path d="M%.1f,%.1f`, points[0].x, points[0].y)
+			for _, p := range points[1:] {
+				e.printf(" L%.1f,%.1f", p.x, p.y)
+			}
+			e.printf(`" marker-end="url(#arrow)"/>` + "\n")
+			label(svgPos{points[1].x, points[1].y - 4}, t.edge.Name())
+		}
+	}
+	e.printf("  </g>\n")
+
+	for _, n := range l.nodes {
+		switch k := kind(n); {
+		case n.vertex == nil:
+		case k != "":
+			e.printf(`  <rect x="%.1f" y="%.1f" width="%.0f" height="%.0f" rx="3" fill="white" stroke="black"/>`+"\n",
+				n.x-svgBoxWidth/2, n.y-svgBoxHeight/2, svgBoxWidth, svgBoxHeight)
+			e.printf(`  <text x="%.1f" y="%.1f" text-anchor="middle">%s</text>`+"\n", n.x, n.y+4, k)
+		default:
+			e.printf(`  <circle cx="%.1f" cy="%.1f" r="%.0f"/>`+"\n", n.x, n.y, svgPoint)
+		}
+	}
+	for _, s := range labels {
+		e.printf("%s", s)
+	}
+	e.printf("</svg>\n")
+	return e.err
+}
@@ -0,0 +1,81 @@
+package gogrex
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLayout(t *testing.T) {
+	var m StringManager
+	g, err := ParseGrex(&m, "a, (b|c)*, d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := g.layout()
+	for _, e := range l.edges {
+		for k := 1; k < len(e.nodes); k++ {
+			if l.nodes[e.nodes[k]].layer != l.nodes[e.nodes[k-1]].layer+1 {
+				t.Errorf("edge %s skips a layer", e.edge.Name())
+			}
+		}
+	}
+	if l.nodes[0].vertex != g.in || l.nodes[0].layer != 0 {
+		t.Errorf("the input is not on the first layer")
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	var m StringManager
+	for _, exp := range []string{"a", "a, (b|c)*", "(a|b), c?, (d, e)+", "a*"} {
+		g, err := ParseGrex(&m, exp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		if err := g.WriteSVG(&b); err != nil {
+			t.Fatal(err)
+		}
+		// must be well formed, with a path per edge
+		d := xml.NewDecoder(bytes.NewReader(b.Bytes()))
+		paths := 0
+		for {
+			tok, err := d.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: invalid SVG: %v\n%s", exp, err, b.String())
+			}
+			if s, ok := tok.(xml.StartElement); ok && s.Name.Local == "path" && s.Name.Space == "http://www.w3.org/2000/svg" {
+				paths++
+			}
+		}
+		// the marker has a path too
+		if paths != len(g.graph.edges)+1 {
+			t.Errorf("%s: %d paths for %d edges", exp, paths-1, len(g.graph.edges))
+		}
+		if strings.Contains(b.String(), "NaN") {
+			t.Errorf("%s: invalid coordinates\n%s", exp, b.String())
+		}
+	}
+}
+
+func TestWriteSVGStable(t *testing.T) {
+	var expected string
+	for i := 0; i < 50; i++ {
+		var m StringManager
+		g, _ := ParseGrex(&m, "(a,c)|(a,b)|(a,b,a)+")
+		var b bytes.Buffer
+		if err := g.WriteSVG(&b); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			expected = b.String()
+		} else if b.String() != expected {
+			t.Fatalf("run %d: the drawing changed", i)
+		}
+	}
+}
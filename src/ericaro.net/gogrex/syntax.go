@@ -0,0 +1,146 @@
+package gogrex
+
+import (
+	"errors"
+	"fmt"
+	"regexp/syntax"
+	"strconv"
+	"unicode"
+)
+
+// An ordinary Go regexp is a regular expression over runes: its grex has one edge per rune, or per range of runes.
+// Edges are named after what they match:
+//
+//	a          the rune 'a', \n or \u00a0 when it is not printable
+//	[a-z]      any rune in the range
+//	(?-s:.)    any rune but newline
+//	(?s:.)     any rune
+//
+// A literal is a single rune, or an escape starting with a backslash: it cannot be mistaken for a class.
+//
+// Anchors (^, $, \A, \z) are ignored: the graph always describes the whole input. Word boundaries are not supported.
+
+//ParseRegexp parses a Go regexp (Perl syntax), and builds its Grex using the Manager.
+func ParseRegexp(m Manager, pattern string) (*Grex, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	return FromSyntax(m, re)
+}
+
+//FromSyntax builds the Grex of a parsed Go regexp, using the Manager. The regexp is simplified first.
+func FromSyntax(m Manager, re *syntax.Regexp) (*Grex, error) {
+	return fromSyntax(m, re.Simplify())
+}
+
+func fromSyntax(m Manager, re *syntax.Regexp) (*Grex, error) {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		g := NewGrex(m) // an input, and no way out
+		g.in = m.NewVertex()
+		g.graph.AddVertex(g.in)
+		return g, nil
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText:
+		return empty(m), nil
+	case syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return nil, errors.New(fmt.Sprintf("unsupported word boundary in %s", re))
+	case syntax.OpLiteral:
+		g := empty(m)
+		for _, r := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 {
+				g = seq(g, foldCase(m, r))
+			} else {
+				g = seq(g, Terminal(m, runeName(r)))
+			}
+		}
+		return g, nil
+	case syntax.OpCharClass:
+		var g *Grex
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			t := Terminal(m, rangeName(re.Rune[i], re.Rune[i+1]))
+			if g == nil {
+				g = t
+			} else {
+				g = sel(g, t)
+			}
+		}
+		if g == nil { // empty class, matches nothing
+			return fromSyntax(m, &syntax.Regexp{Op: syntax.OpNoMatch})
+		}
+		return g, nil
+	case syntax.OpAnyCharNotNL:
+		return Terminal(m, "(?-s:.)"), nil
+	case syntax.OpAnyChar:
+		return Terminal(m, "(?s:.)"), nil
+	case syntax.OpCapture:
+		return fromSyntax(m, re.Sub[0])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		sub, err := fromSyntax(m, re.Sub[0])
+		if err != nil {
+			return nil, err
+		}
+		switch re.Op {
+		case syntax.OpStar:
+			return star(sub), nil
+		case syntax.OpPlus:
+			return plus(sub), nil
+		case syntax.OpQuest:
+			return opt(sub), nil
+		}
+		if re.Min > MaxRepeat || re.Max > MaxRepeat {
+			return nil, errors.New(fmt.Sprintf("repetition greater than %d in %s", MaxRepeat, re))
+		}
+		g, err := repeat(sub, re.Min, re.Max)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("repetition too large in %s, %s", re, err))
+		}
+		return g, nil
+	case syntax.OpConcat, syntax.OpAlternate:
+		var g *Grex
+		for _, s := range re.Sub {
+			sub, err := fromSyntax(m, s)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case g == nil:
+				g = sub
+			case re.Op == syntax.OpConcat:
+				g = seq(g, sub)
+			default:
+				g = sel(g, sub)
+			}
+		}
+		if g == nil {
+			return empty(m), nil
+		}
+		return g, nil
+	}
+	return nil, errors.New(fmt.Sprintf("unsupported regexp operator %s", re))
+}
+
+//foldCase returns the selection of every case of the rune
+func foldCase(m Manager, r rune) *Grex {
+	g := Terminal(m, runeName(r))
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		g = sel(g, Terminal(m, runeName(f)))
+	}
+	return g
+}
+
+//runeName is the rune itself, escaped when not printable
+func runeName(r rune) string {
+	if unicode.IsGraphic(r) && r != ' ' {
+		return string(r)
+	}
+	q := strconv.QuoteRune(r)
+	return q[1 : len(q)-1]
+}
+
+func rangeName(lo, hi rune) string {
+	if lo == hi {
+		return runeName(lo)
+	}
+	return fmt.Sprintf("[%s-%s]", runeName(lo), runeName(hi))
+}
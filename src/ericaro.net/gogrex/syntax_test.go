@@ -0,0 +1,51 @@
+package gogrex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRegexp(t *testing.T) {
+	cases := []struct {
+		pattern  string
+		accepted []string
+		rejected []string
+	}{
+		{`[a-z]+@[a-z]+`, []string{"[a-z] @ [a-z]", "[a-z] [a-z] @ [a-z]"}, []string{"@ [a-z]", "[a-z] @"}},
+		{`ab?c{2,3}`, []string{"a c c", "a b c c c"}, []string{"a b c", "a c c c c"}},
+		{`(?i)k|x*`, []string{"k", "K", "K", "", "x x"}, []string{"k k"}},
+		{`^a.\n$`, []string{`a (?-s:.) \n`}, []string{"a", `a . \n`}},
+		{`a\.(?s:.)`, []string{`a . (?s:.)`}, []string{`a (?-s:.) (?s:.)`}},
+		{`[^a]`, []string{`[\x00-` + "`]", `[b-\U0010ffff]`}, []string{"a"}},
+	}
+	for _, c := range cases {
+		var m StringManager
+		g, err := ParseRegexp(&m, c.pattern)
+		if err != nil {
+			t.Fatalf("%s: %v", c.pattern, err)
+		}
+		for _, s := range c.accepted {
+			if !g.Match(strings.Fields(s)) {
+				t.Errorf("%s: %q should be accepted", c.pattern, s)
+			}
+		}
+		for _, s := range c.rejected {
+			if g.Match(strings.Fields(s)) {
+				t.Errorf("%s: %q should be rejected", c.pattern, s)
+			}
+		}
+	}
+	var m StringManager
+	// names survive the dot format
+	g, _ := ParseRegexp(&m, `a.\.\n\\"[\x00-\x01]`)
+	r, err := ReadDot(&m, strings.NewReader(g.String()))
+	if err != nil {
+		t.Fatalf("%v\n%s", err, g)
+	}
+	if s := []string{"a", "(?-s:.)", ".", `\n`, `\`, `"`, `[\x00-\x01]`}; !r.Match(s) {
+		t.Errorf("%v was not read back\n%s", s, g)
+	}
+	if _, err := ParseRegexp(&m, `\bword`); err == nil {
+		t.Errorf("word boundaries should not be supported")
+	}
+}
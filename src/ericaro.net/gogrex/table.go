@@ -0,0 +1,237 @@
+package gogrex
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// A Table is a determinized grex reduced to plain tables, ready to be shipped and matched without any graph.
+//
+// Binary format, every integer is an unsigned varint:
+//
+//	"GRX" version(1 byte)
+//	symbols count, then for each symbol: length, bytes      sorted
+//	states count
+//	for each state: transitions count, then for each: symbol, target
+//	accept bitset: (states+7)/8 bytes, state s is bit s%8 of byte s/8
+//
+// The start state is 0.
+
+const (
+	tableMagic   = "GRX"
+	tableVersion = 1
+	maxTable     = 1 << 24 // maximum number of cells of the transition table that can be decoded
+)
+
+//Table is a determinized grex stored as tables. It implements encoding.BinaryMarshaler and encoding.BinaryUnmarshaler.
+type Table struct {
+	states  int      // number of states
+	symbols []string // sorted alphabet
+	next    []int32  // next[state*len(symbols)+symbol], -1 when there is no transition
+	accept  []byte   // bitset of accepting states
+}
+
+//Table determinizes the grex into a Table.
+func (g *Grex) Table() *Table {
+	d := g.determinize()
+	t := &Table{
+		states:  len(d.next),
+		symbols: d.symbols,
+		next:    make([]int32, len(d.next)*len(d.symbols)),
+		accept:  make([]byte, (len(d.next)+7)/8),
+	}
+	for s, next := range d.next {
+		for a, n := range next {
+			t.next[s*len(d.symbols)+a] = int32(n)
+		}
+		if d.accept[s] {
+			t.accept[s/8] |= 1 << uint(s%8)
+		}
+	}
+	return t
+}
+
+//States returns the number of states of the table.
+func (t *Table) States() int {
+	return t.states
+}
+
+//Symbols returns the sorted alphabet of the table.
+func (t *Table) Symbols() []string {
+	return append([]string(nil), t.symbols...)
+}
+
+//accepts tells if the state is accepting
+func (t *Table) accepts(s int) bool {
+	return t.accept[s/8]&(1<<uint(s%8)) != 0
+}
+
+//step returns the next state, or -1
+func (t *Table) step(s int, symbol string) int {
+	a := sort.SearchStrings(t.symbols, symbol)
+	if a == len(t.symbols) || t.symbols[a] != symbol {
+		return -1
+	}
+	return int(t.next[s*len(t.symbols)+a])
+}
+
+//Match tells if the whole sequence of symbols is accepted. It does not allocate.
+func (t *Table) Match(symbols []string) bool {
+	s := 0
+	for _, symbol := range symbols {
+		if s = t.step(s, symbol); s < 0 {
+			return false
+		}
+	}
+	return t.accepts(s)
+}
+
+//MarshalBinary encodes the table.
+func (t *Table) MarshalBinary() ([]byte, error) {
+	buf := append([]byte(tableMagic), tableVersion)
+	tmp := make([]byte, binary.MaxVarintLen64)
+	put := func(v int) {
+		buf = append(buf, tmp[:binary.PutUvarint(tmp, uint64(v))]...)
+	}
+	put(len(t.symbols))
+	for _, s := range t.symbols {
+		put(len(s))
+		buf = append(buf, s...)
+	}
+	put(t.states)
+	for s := 0; s < t.states; s++ {
+		row := t.next[s*len(t.symbols) : (s+1)*len(t.symbols)]
+		count := 0
+		for _, n := range row {
+			if n >= 0 {
+				count++
+			}
+		}
+		put(count)
+		for a, n := range row {
+			if n >= 0 {
+				put(a)
+				put(int(n))
+			}
+		}
+	}
+	buf = append(buf, t.accept...)
+	return buf, nil
+}
+
+//UnmarshalBinary decodes a table encoded by MarshalBinary.
+func (t *Table) UnmarshalBinary(data []byte) error {
+	if len(data) < len(tableMagic)+1 || string(data[:len(tableMagic)]) != tableMagic {
+		return errors.New("gogrex: not a table")
+	}
+	if v := data[len(tableMagic)]; v != tableVersion {
+		return errors.New(fmt.Sprintf("gogrex: unsupported table version %d", v))
+	}
+	data = data[len(tableMagic)+1:]
+	var err error
+	get := func(max int) int {
+		v, n := binary.Uvarint(data)
+		if n <= 0 || v > uint64(max) {
+			if err == nil {
+				err = errors.New("gogrex: corrupted table")
+			}
+			return 0
+		}
+		data = data[n:]
+		return int(v)
+	}
+
+	symbols := make([]string, get(len(data)))
+	for i := range symbols {
+		l := get(len(data))
+		if err == nil && l > len(data) {
+			err = errors.New("gogrex: corrupted table")
+		}
+		if err != nil {
+			return err
+		}
+		symbols[i] = string(data[:l])
+		data = data[l:]
+	}
+	states := get(len(data)) // every state costs at least a byte: its transitions count
+	if err != nil {
+		return err
+	}
+	// the table is dense: a few bytes of sparse transitions can stand for a large table
+	if len(symbols) > 0 && states > maxTable/len(symbols) {
+		return errors.New(fmt.Sprintf("gogrex: table too large, %d states of %d symbols", states, len(symbols)))
+	}
+	for i, symbol := range symbols {
+		if symbol == "" || i > 0 && symbols[i-1] >= symbol {
+			return errors.New("gogrex: corrupted table")
+		}
+	}
+	next := make([]int32, states*len(symbols))
+	for i := range next {
+		next[i] = -1
+	}
+	for s := 0; s < states; s++ {
+		count := get(len(symbols))
+		for i := 0; i < count; i++ {
+			a := get(len(symbols) - 1)
+			n := get(states - 1)
+			if err != nil {
+				return err
+			}
+			next[s*len(symbols)+a] = int32(n)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if states == 0 || len(data) != (states+7)/8 {
+		return errors.New("gogrex: corrupted table")
+	}
+	t.states, t.symbols, t.next, t.accept = states, symbols, next, append([]byte(nil), data...)
+	return nil
+}
+
+//TableMatcher reads a sequence of symbols one at a time on a Table, with the same semantic as Matcher.
+type TableMatcher struct {
+	table   *Table
+	current int
+}
+
+//NewMatcher creates a matcher on the start state.
+func (t *Table) NewMatcher() *TableMatcher {
+	return &TableMatcher{table: t}
+}
+
+//Reset goes back to the start state.
+func (m *TableMatcher) Reset() {
+	m.current = 0
+}
+
+//Next reads a symbol, and returns false if it is not expected. In that case the symbol is ignored.
+func (m *TableMatcher) Next(symbol string) bool {
+	n := m.table.step(m.current, symbol)
+	if n < 0 {
+		return false
+	}
+	m.current = n
+	return true
+}
+
+//Accepts tells if the symbols read so far are a complete sequence.
+func (m *TableMatcher) Accepts() bool {
+	return m.table.accepts(m.current)
+}
+
+//Expected returns the sorted list of symbols that can be read next.
+func (m *TableMatcher) Expected() []string {
+	expected := []string{}
+	row := m.table.next[m.current*len(m.table.symbols) : (m.current+1)*len(m.table.symbols)]
+	for a, n := range row {
+		if n >= 0 {
+			expected = append(expected, m.table.symbols[a])
+		}
+	}
+	return expected
+}
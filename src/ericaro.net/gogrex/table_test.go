@@ -0,0 +1,99 @@
+package gogrex
+
+import (
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = &Table{}
+	_ encoding.BinaryUnmarshaler = &Table{}
+	_ SequenceMatcher            = &TableMatcher{}
+)
+
+func TestTable(t *testing.T) {
+	symbols := []string{"a", "b", "c", "x", "end"}
+	for _, exp := range append([]string{"a|b", "(a,b)|(a,c)", "a*,a"}, exps[3:]...) {
+		var m StringManager
+		g, err := ParseGrex(&m, exp)
+		if err != nil {
+			t.Fatalf("%s: %v", exp, err)
+		}
+		data, err := g.Table().MarshalBinary()
+		if err != nil {
+			t.Fatalf("%s: %v", exp, err)
+		}
+		var table Table
+		if err := table.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%s: %v", exp, err)
+		}
+		for _, s := range sequences(symbols, 4) {
+			if g.Match(s) != table.Match(s) {
+				t.Errorf("%s on %v: the table is not equivalent", exp, s)
+			}
+			gm, tm := g.NewMatcher(), table.NewMatcher()
+			for _, sym := range s {
+				if !reflect.DeepEqual(gm.Expected(), tm.Expected()) {
+					t.Fatalf("%s on %v: expected %v, table expects %v", exp, s, gm.Expected(), tm.Expected())
+				}
+				gm.Next(sym)
+				tm.Next(sym)
+			}
+		}
+		// any truncation is detected
+		for i := 0; i < len(data); i++ {
+			if err := new(Table).UnmarshalBinary(data[:i]); err == nil {
+				t.Errorf("%s: truncated table at %d was accepted", exp, i)
+			}
+		}
+	}
+}
+
+func TestTableMatchAllocations(t *testing.T) {
+	var m StringManager
+	g, _ := ParseGrex(&m, "(timing, (id,value)+ )*, startDefinition, (id,name)*, endDefinition")
+	table := g.Table()
+	input := []string{"timing", "id", "value", "id", "value", "startDefinition", "id", "name", "endDefinition"}
+	if allocs := testing.AllocsPerRun(100, func() { table.Match(input) }); allocs != 0 {
+		t.Errorf("Match allocates %v times", allocs)
+	}
+}
+
+func TestTableCorrupted(t *testing.T) {
+	table := func(symbols []string, states int) []byte {
+		data := []byte(tableMagic + "\x01")
+		data = binary.AppendUvarint(data, uint64(len(symbols)))
+		for _, s := range symbols {
+			data = binary.AppendUvarint(data, uint64(len(s)))
+			data = append(data, s...)
+		}
+		data = binary.AppendUvarint(data, uint64(states))
+		data = append(data, make([]byte, states+(states+7)/8)...) // no transitions, no accepting state
+		return data
+	}
+	if err := new(Table).UnmarshalBinary(table([]string{"a", "b"}, 2)); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	for name, data := range map[string][]byte{
+		"duplicate symbols": table([]string{"a", "a"}, 1),
+		"unsorted symbols":  table([]string{"b", "a"}, 1),
+		"empty symbol":      table([]string{"", "a"}, 1),
+		"too many states":   table([]string{"a"}, 4)[:len(table([]string{"a"}, 4))-5],
+	} {
+		if err := new(Table).UnmarshalBinary(data); err == nil {
+			t.Errorf("%s: the table was accepted", name)
+		}
+	}
+
+	// a few kilobytes cannot allocate a huge table
+	symbols := make([]string, 5000)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("%05d", i)
+	}
+	if err := new(Table).UnmarshalBinary(table(symbols, 4000)); err == nil {
+		t.Errorf("a table of %d cells was accepted", 5000*4000)
+	}
+}
@@ -0,0 +1,86 @@
+package gogrex
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// XML Schema and DTD require content models to be deterministic: while reading a sequence, every symbol must be
+// attributed to a single occurrence in the expression (Unique Particle Attribution). "(a,b)|(a,c)" is not, because
+// when reading "a" we cannot tell which "a" of the expression it is.
+//
+// On the graph, it means that a vertex has two outbound edges with the same name, coming from different identifiers
+// in the expression. Edges cloned by "+" or "*" share the same Span, so they are not considered as conflicting.
+
+//Ambiguity describes a vertex where a symbol can be attributed to several occurrences of the expression.
+type Ambiguity struct {
+	Vertex Vertex // the vertex where the choice cannot be made
+	Name   string // the ambiguous symbol
+	Spans  []Span // the conflicting occurrences in the source expression, sorted
+}
+
+func (a Ambiguity) String() string {
+	spans := make([]string, len(a.Spans))
+	for i, s := range a.Spans {
+		spans[i] = s.String()
+	}
+	return fmt.Sprintf("ambiguous %q at %s", a.Name, strings.Join(spans, ", "))
+}
+
+//Ambiguities returns every vertex where the grex breaks the unique particle attribution constraint.
+// Edges without Span (not built by parsing an expression) are ignored.
+// The result is sorted by position in the expression, and empty if the grex is deterministic.
+func (g *Grex) Ambiguities() (ambiguities []Ambiguity) {
+	for v := range g.graph.vertices {
+		// group the distinct occurrences by name
+		occurrences := make(map[string]map[Span]interface{})
+		for _, t := range g.graph.OutEdges(v) {
+			span, ok := g.spans[t]
+			if !ok {
+				continue
+			}
+			if occurrences[t.Name()] == nil {
+				occurrences[t.Name()] = make(map[Span]interface{})
+			}
+			occurrences[t.Name()][span] = nil
+		}
+		for name, spans := range occurrences {
+			if len(spans) < 2 {
+				continue // a single occurrence, that's fine
+			}
+			a := Ambiguity{Vertex: v, Name: name}
+			for s := range spans {
+				a.Spans = append(a.Spans, s)
+			}
+			sortSpans(a.Spans)
+			ambiguities = append(ambiguities, a)
+		}
+	}
+	// the same conflict is usually found on several vertices (think "(a|a)+"), keep one of them.
+	sort.Slice(ambiguities, func(i, j int) bool { return lessSpans(ambiguities[i].Spans, ambiguities[j].Spans) })
+	unique := ambiguities[:0]
+	for i, a := range ambiguities {
+		if i > 0 && !lessSpans(ambiguities[i-1].Spans, a.Spans) {
+			continue
+		}
+		unique = append(unique, a)
+	}
+	return unique
+}
+
+func sortSpans(spans []Span) {
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].Start < spans[j].Start || spans[i].Start == spans[j].Start && spans[i].End < spans[j].End
+	})
+}
+
+//lessSpans compares two sorted slices of spans
+func lessSpans(a, b []Span) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i].Start < b[i].Start || a[i].Start == b[i].Start && a[i].End < b[i].End
+		}
+	}
+	return len(a) < len(b)
+}
@@ -0,0 +1,54 @@
+package gogrex
+
+import (
+	"testing"
+)
+
+func TestAmbiguities(t *testing.T) {
+	deterministic := []string{
+		"a",
+		"(a,b)*",
+		"a,(b|c)",
+		"(a, b+ )*, end",
+		"(timing, (id,value)+ )*, startDefinition, (id,name)*, endDefinition",
+	}
+	for _, exp := range deterministic {
+		var m StringManager
+		g, err := ParseGrex(&m, exp)
+		if err != nil {
+			t.Fatalf("%s: %v", exp, err)
+		}
+		if a := g.Ambiguities(); len(a) != 0 {
+			t.Errorf("%s: unexpected ambiguities %v", exp, a)
+		}
+	}
+
+	ambiguous := []struct {
+		exp   string
+		name  string
+		spans []Span
+	}{
+		{"(a,b)|(a,c)", "a", []Span{{1, 2}, {7, 8}}},
+		{"a*, a", "a", []Span{{0, 1}, {4, 5}}},
+		{"(x|x)+", "x", []Span{{1, 2}, {3, 4}}},
+	}
+	for _, c := range ambiguous {
+		var m StringManager
+		g, err := ParseGrex(&m, c.exp)
+		if err != nil {
+			t.Fatalf("%s: %v", c.exp, err)
+		}
+		a := g.Ambiguities()
+		if len(a) != 1 {
+			t.Fatalf("%s: expected one ambiguity got %v", c.exp, a)
+		}
+		if a[0].Name != c.name || len(a[0].Spans) != len(c.spans) {
+			t.Fatalf("%s: unexpected ambiguity %v", c.exp, a[0])
+		}
+		for i, s := range c.spans {
+			if a[0].Spans[i] != s {
+				t.Errorf("%s: expected %v got %v", c.exp, c.spans, a[0].Spans)
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package gogrex
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Validation streams an XML document, and checks that the children of every element follow the element's grex.
+// Only element names are checked: character data, attributes and namespaces are ignored.
+
+//ValidationError reports an element whose content does not follow its grex.
+type ValidationError struct {
+	Line, Column int      // position of the offending tag
+	Element      string   // the element whose content is invalid
+	Found        string   // the unexpected child, "" if the element ended too early
+	Expected     []string // the children that were expected instead, "</Element>" when the element could end
+	Undeclared   bool     // true if Element has no grex at all
+}
+
+func (e *ValidationError) Error() string {
+	switch {
+	case e.Undeclared:
+		return fmt.Sprintf("line %d:%d: <%s> is not declared", e.Line, e.Column, e.Element)
+	case e.Found == "":
+		return fmt.Sprintf("line %d:%d: <%s> ended too early, expected %s", e.Line, e.Column, e.Element, strings.Join(e.Expected, ", "))
+	}
+	return fmt.Sprintf("line %d:%d: <%s> not allowed in <%s>, expected %s", e.Line, e.Column, e.Found, e.Element, strings.Join(e.Expected, ", "))
+}
+
+//frame is an element being read
+type frame struct {
+	name    string
+	matcher *Matcher // nil if the element is not declared
+}
+
+//expected lists what the frame could read, including its own end tag
+func (f frame) expected() []string {
+	expected := f.matcher.Expected()
+	if f.matcher.Accepts() {
+		expected = append(expected, "</"+f.name+">")
+	}
+	return expected
+}
+
+//Validate reads the XML document, and checks every element against the grex of the same name (usually read from a DTD).
+// It reports every invalid element in the document order. Undeclared elements are reported once, and their content is not checked.
+// err is only set if the document could not be read (malformed XML, i/o error).
+func Validate(r io.Reader, elements map[string]*Grex) ([]*ValidationError, error) {
+	decoder := xml.NewDecoder(r)
+	var invalids []*ValidationError
+	var stack []frame
+	for {
+		line, column := decoder.InputPos() // the start of the next token, character data is a token too
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return invalids, nil
+		}
+		if err != nil {
+			return invalids, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+			if len(stack) > 0 {
+				if parent := stack[len(stack)-1]; parent.matcher != nil {
+					expected := parent.expected()
+					if !parent.matcher.Next(name) {
+						invalids = append(invalids, &ValidationError{Line: line, Column: column, Element: parent.name, Found: name, Expected: expected})
+					}
+				}
+			}
+			f := frame{name: name}
+			if g, ok := elements[name]; ok {
+				f.matcher = g.NewMatcher()
+			} else {
+				invalids = append(invalids, &ValidationError{Line: line, Column: column, Element: name, Undeclared: true})
+			}
+			stack = append(stack, f)
+		case xml.EndElement:
+			f := stack[len(stack)-1] // the decoder checks that tags are balanced
+			stack = stack[:len(stack)-1]
+			if f.matcher != nil && !f.matcher.Accepts() {
+				invalids = append(invalids, &ValidationError{Line: line, Column: column, Element: f.name, Expected: f.expected()})
+			}
+		}
+	}
+}
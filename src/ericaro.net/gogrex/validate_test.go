@@ -0,0 +1,95 @@
+package gogrex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatcher(t *testing.T) {
+	var m StringManager
+	g, err := ParseGrex(&m, "name, alias?, (telephone|email)+")
+	if err != nil {
+		t.Fatal(err)
+	}
+	accepted := [][]string{
+		{"name", "email"},
+		{"name", "alias", "telephone", "email", "telephone"},
+	}
+	for _, s := range accepted {
+		if !g.Match(s) {
+			t.Errorf("%v should be accepted", s)
+		}
+	}
+	rejected := [][]string{
+		{},
+		{"name"},
+		{"name", "alias", "alias", "email"},
+		{"email", "name"},
+	}
+	for _, s := range rejected {
+		if g.Match(s) {
+			t.Errorf("%v should be rejected", s)
+		}
+	}
+
+	matcher := g.NewMatcher()
+	matcher.Next("name")
+	if got := strings.Join(matcher.Expected(), " "); got != "alias email telephone" {
+		t.Errorf("unexpected expected symbols %q", got)
+	}
+	if matcher.Next("name") || matcher.Accepts() {
+		t.Errorf("name should not be accepted twice")
+	}
+	if !matcher.Next("email") || !matcher.Accepts() {
+		t.Errorf("the matcher should recover after an unexpected symbol")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var m StringManager
+	elements, err := ReadDTD(&m, strings.NewReader(testDTD))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := `<book>
+	<person id="1"><name>John</name><alias>J<b>o</b>hn</alias><email>john@doe</email><telephone/></person>
+	<person id="2"><name>Jane</name><email>jane@doe</email></person>
+</book>`
+	invalids, err := Validate(strings.NewReader(valid), elements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(invalids) != 0 {
+		t.Errorf("unexpected errors %v", invalids)
+	}
+
+	invalid := `<book>
+	<person id="1"><email>john@doe</email></person>
+	<person id="2"><name>Jane</name></person>
+	<unknown/>
+</book>`
+	invalids, err = Validate(strings.NewReader(invalid), elements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	goldens := []string{
+		"line 2:17: <email> not allowed in <person>, expected name",
+		"line 2:40: <person> ended too early, expected name",
+		"line 3:34: <person> ended too early, expected alias, email, telephone",
+		"line 4:2: <unknown> not allowed in <book>, expected person, </book>",
+		"line 4:2: <unknown> is not declared",
+	}
+	if len(invalids) != len(goldens) {
+		t.Fatalf("expected %d errors, got %v", len(goldens), invalids)
+	}
+	for i, g := range goldens {
+		if invalids[i].Error() != g {
+			t.Errorf("expected %q got %q", g, invalids[i].Error())
+		}
+	}
+
+	if _, err := Validate(strings.NewReader("<book><person></book>"), elements); err == nil {
+		t.Errorf("malformed documents should fail")
+	}
+}
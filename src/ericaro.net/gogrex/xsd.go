@@ -0,0 +1,350 @@
+package gogrex
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// XML Schema particles are translated into grexes with the builder functions:
+//
+//	xs:sequence      Seq
+//	xs:choice        Sel
+//	xs:all           every order of its elements, but those with maxOccurs="0"
+//	xs:element       Terminal
+//	xs:group ref     the particle of the named group
+//	minOccurs, maxOccurs  Repeat, an error beyond MaxRepeatSize
+//
+// Like DTD content models, only child element names are part of the graph: attributes, simple types and
+// character data are ignored, simple content is empty.
+// Wildcards (xs:any), substitution groups and complexContent restriction of named types are not supported.
+
+//maxAll is the largest xs:all supported, its graph has 2^n vertices.
+const maxAll = 16
+
+//xsdNode is any element of the schema. Particles are read as a generic tree because their order matters.
+type xsdNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []xsdNode  `xml:",any"`
+}
+
+//attr returns the value of an unqualified attribute
+func (n *xsdNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name && a.Name.Space == "" {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+//children returns the children with a given local name
+func (n *xsdNode) children(name string) (children []*xsdNode) {
+	for i := range n.Children {
+		if n.Children[i].XMLName.Local == name {
+			children = append(children, &n.Children[i])
+		}
+	}
+	return
+}
+
+//child returns the first child among the given local names, or nil
+func (n *xsdNode) child(names ...string) *xsdNode {
+	for i := range n.Children {
+		for _, name := range names {
+			if n.Children[i].XMLName.Local == name {
+				return &n.Children[i]
+			}
+		}
+	}
+	return nil
+}
+
+//ReadXSD reads an XML Schema, and builds the Grex of every element declaration, using the Manager.
+// The returned map is indexed by element name. Global declarations take precedence over local ones with the same name.
+func ReadXSD(m Manager, r io.Reader) (map[string]*Grex, error) {
+	var schema xsdNode
+	if err := xml.NewDecoder(r).Decode(&schema); err != nil {
+		return nil, err
+	}
+	if schema.XMLName.Local != "schema" {
+		return nil, errors.New(fmt.Sprintf("xsd: unexpected root element %s", schema.XMLName.Local))
+	}
+	x := &xsdReader{
+		manager:  m,
+		types:    make(map[string]*xsdNode),
+		groups:   make(map[string]*xsdNode),
+		elements: make(map[string]*Grex),
+		inGroups: make(map[string]bool),
+	}
+	for _, t := range schema.children("complexType") {
+		x.types[t.attr("name")] = t
+	}
+	for _, g := range schema.children("group") {
+		x.groups[g.attr("name")] = g
+	}
+	globals := schema.children("element")
+	for _, e := range globals { // globals first, so that they take precedence
+		g, err := x.content(e)
+		if err != nil {
+			return nil, err
+		}
+		x.elements[e.attr("name")] = g
+	}
+	return x.elements, nil
+}
+
+//xsdReader holds the named definitions of a schema
+type xsdReader struct {
+	manager  Manager
+	types    map[string]*xsdNode // named complex types
+	groups   map[string]*xsdNode // named model groups
+	elements map[string]*Grex    // content of every element found so far
+	visiting []string            // types being translated, to detect recursive extensions
+	inGroups map[string]bool     // groups being translated, to detect circular references
+}
+
+//content builds the grex of an element declaration content.
+func (x *xsdReader) content(e *xsdNode) (*Grex, error) {
+	if t := e.child("complexType"); t != nil {
+		return x.complexType(t)
+	}
+	name := localName(e.attr("type"))
+	if t, ok := x.types[name]; ok {
+		return x.complexType(t)
+	}
+	return empty(x.manager), nil // simple type, built-in type, or no type at all
+}
+
+//complexType builds the grex of a complex type content.
+func (x *xsdReader) complexType(t *xsdNode) (*Grex, error) {
+	if c := t.child("complexContent"); c != nil {
+		return x.complexContent(c)
+	}
+	if p := t.child("sequence", "choice", "all", "group"); p != nil {
+		return x.particle(p)
+	}
+	return empty(x.manager), nil // simple content, or attributes only
+}
+
+//complexContent builds the grex of a type derived by extension: the base type particle followed by the extension one.
+func (x *xsdReader) complexContent(c *xsdNode) (*Grex, error) {
+	ext := c.child("extension")
+	if ext == nil {
+		if res := c.child("restriction"); res != nil {
+			// a restriction repeats the whole content model
+			if p := res.child("sequence", "choice", "all", "group"); p != nil {
+				return x.particle(p)
+			}
+			return empty(x.manager), nil
+		}
+		return nil, errors.New("xsd: invalid complexContent")
+	}
+	name := localName(ext.attr("base"))
+	for _, v := range x.visiting {
+		if v == name {
+			return nil, errors.New(fmt.Sprintf("xsd: type %s extends itself", name))
+		}
+	}
+	base := empty(x.manager)
+	if t, ok := x.types[name]; ok {
+		x.visiting = append(x.visiting, name)
+		b, err := x.complexType(t)
+		x.visiting = x.visiting[:len(x.visiting)-1]
+		if err != nil {
+			return nil, err
+		}
+		base = b
+	}
+	p := ext.child("sequence", "choice", "all", "group")
+	if p == nil {
+		return base, nil
+	}
+	g, err := x.particle(p)
+	if err != nil {
+		return nil, err
+	}
+	return seq(base, g), nil
+}
+
+//particle builds the grex of a particle, including its occurrences.
+func (x *xsdReader) particle(p *xsdNode) (*Grex, error) {
+	min, max, err := occurs(p)
+	if err != nil {
+		return nil, err
+	}
+	var g *Grex
+	switch p.XMLName.Local {
+	case "element":
+		g, err = x.element(p)
+	case "sequence", "choice":
+		g, err = x.group(p)
+	case "all":
+		g, err = x.all(p)
+	case "group":
+		ref := localName(p.attr("ref"))
+		def, ok := x.groups[ref]
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("xsd: unknown group %s", ref))
+		}
+		model := def.child("sequence", "choice", "all")
+		if model == nil {
+			return nil, errors.New(fmt.Sprintf("xsd: empty group %s", ref))
+		}
+		if x.inGroups[ref] {
+			return nil, errors.New(fmt.Sprintf("xsd: group %s refers to itself", ref))
+		}
+		x.inGroups[ref] = true
+		g, err = x.particle(model)
+		delete(x.inGroups, ref)
+	case "any":
+		return nil, errors.New("xsd: xs:any is not supported")
+	default:
+		return nil, errors.New(fmt.Sprintf("xsd: unexpected %s", p.XMLName.Local))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if min == 1 && max == 1 {
+		return g, nil
+	}
+	if g, err = repeat(g, min, max); err != nil {
+		return nil, errors.New(fmt.Sprintf("xsd: occurrences are too large, %s", err))
+	}
+	return g, nil
+}
+
+//element is an element particle: a single symbol. Local declarations are also recorded in the elements.
+func (x *xsdReader) element(e *xsdNode) (*Grex, error) {
+	if ref := e.attr("ref"); ref != "" {
+		return Terminal(x.manager, localName(ref)), nil
+	}
+	name := e.attr("name")
+	if _, ok := x.elements[name]; !ok {
+		x.elements[name] = nil // placeholder, for recursive elements
+		g, err := x.content(e)
+		if err != nil {
+			return nil, err
+		}
+		x.elements[name] = g
+	}
+	return Terminal(x.manager, name), nil
+}
+
+//group is a sequence or a choice of particles
+func (x *xsdReader) group(p *xsdNode) (*Grex, error) {
+	var g *Grex
+	for i := range p.Children {
+		c := &p.Children[i]
+		if c.XMLName.Local == "annotation" {
+			continue
+		}
+		cg, err := x.particle(c)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case g == nil:
+			g = cg
+		case p.XMLName.Local == "sequence":
+			g = seq(g, cg)
+		default:
+			g = sel(g, cg)
+		}
+	}
+	if g == nil {
+		if p.XMLName.Local == "choice" {
+			return nil, errors.New("xsd: empty choice")
+		}
+		return empty(x.manager), nil
+	}
+	return g, nil
+}
+
+//all accepts its elements in any order. The graph is built directly: a vertex is the subset of elements already read.
+func (x *xsdReader) all(p *xsdNode) (*Grex, error) {
+	var names []string
+	var required uint
+	for _, e := range p.children("element") {
+		min, max, err := occurs(e)
+		if err != nil {
+			return nil, err
+		}
+		if max == 0 { // the element is excluded
+			continue
+		}
+		if max > 1 || max < 0 {
+			return nil, errors.New("xsd: elements of xs:all cannot repeat")
+		}
+		if min == 1 {
+			required |= 1 << uint(len(names))
+		}
+		if _, err := x.element(e); err != nil {
+			return nil, err
+		}
+		if e.attr("ref") != "" {
+			names = append(names, localName(e.attr("ref")))
+		} else {
+			names = append(names, e.attr("name"))
+		}
+	}
+	if len(names) > maxAll {
+		return nil, errors.New(fmt.Sprintf("xsd: xs:all is limited to %d elements", maxAll))
+	}
+	g := NewGrex(x.manager)
+	vertices := make([]Vertex, 1<<uint(len(names)))
+	for set := range vertices {
+		vertices[set] = x.manager.NewVertex()
+		g.graph.AddVertex(vertices[set])
+		if uint(set)&required == required {
+			g.outs[vertices[set]] = nil
+		}
+	}
+	for set := range vertices {
+		for i, name := range names {
+			if set&(1<<uint(i)) == 0 {
+				g.graph.AddEdge(x.manager.NewEdge(name), vertices[set], vertices[set|1<<uint(i)])
+			}
+		}
+	}
+	g.in = vertices[0]
+	return g, nil
+}
+
+//occurs reads minOccurs and maxOccurs, max is -1 when unbounded.
+func occurs(p *xsdNode) (min, max int, err error) {
+	min, max = 1, 1
+	if v := p.attr("minOccurs"); v != "" {
+		if min, err = strconv.Atoi(v); err != nil || min < 0 {
+			return 0, 0, errors.New(fmt.Sprintf("xsd: invalid minOccurs %q", v))
+		}
+	}
+	switch v := p.attr("maxOccurs"); v {
+	case "":
+	case "unbounded":
+		max = -1
+	default:
+		if max, err = strconv.Atoi(v); err != nil || max < 0 {
+			return 0, 0, errors.New(fmt.Sprintf("xsd: invalid maxOccurs %q", v))
+		}
+	}
+	if max >= 0 && min > max {
+		return 0, 0, errors.New(fmt.Sprintf("xsd: minOccurs %d greater than maxOccurs %d", min, max))
+	}
+	if min > MaxRepeat || max > MaxRepeat {
+		return 0, 0, errors.New(fmt.Sprintf("xsd: occurrences are limited to %d", MaxRepeat))
+	}
+	return
+}
+
+//localName strips the namespace prefix of a qualified name
+func localName(qname string) string {
+	if i := strings.LastIndex(qname, ":"); i >= 0 {
+		return qname[i+1:]
+	}
+	return qname
+}
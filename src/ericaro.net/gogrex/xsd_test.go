@@ -0,0 +1,149 @@
+package gogrex
+
+import (
+	"strings"
+	"testing"
+)
+
+const testXSD = `<?xml version="1.0"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="book">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element ref="person" minOccurs="0" maxOccurs="unbounded"/>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+  <xs:element name="person" type="personType"/>
+  <xs:complexType name="personType">
+    <xs:sequence>
+      <xs:element name="name" type="xs:string"/>
+      <xs:element name="alias" type="xs:string" minOccurs="0" maxOccurs="2"/>
+      <xs:choice minOccurs="1" maxOccurs="unbounded">
+        <xs:element name="telephone" type="xs:string"/>
+        <xs:element name="email" type="xs:string"/>
+      </xs:choice>
+      <xs:group ref="address" minOccurs="0"/>
+    </xs:sequence>
+  </xs:complexType>
+  <xs:group name="address">
+    <xs:all>
+      <xs:element name="street"/>
+      <xs:element name="city"/>
+      <xs:element name="zip" minOccurs="0"/>
+    </xs:all>
+  </xs:group>
+</xs:schema>`
+
+func TestRepeat(t *testing.T) {
+	var m StringManager
+	g := Repeat(Terminal(&m, "a"), 2, 4)
+	for n, accepted := range []bool{false, false, true, true, true, false} {
+		s := make([]string, n)
+		for i := range s {
+			s[i] = "a"
+		}
+		if g.Match(s) != accepted {
+			t.Errorf("a{2,4} on %v: expected %v", s, accepted)
+		}
+	}
+	if g := Repeat(Terminal(&m, "a"), 0, 0); !g.Match(nil) || g.Match([]string{"a"}) {
+		t.Errorf("a{0,0} should only accept the empty sequence")
+	}
+	// the optional copies are nested, the grex is not ambiguous
+	if g := Repeat(Terminal(&m, "a"), 1, 3); len(g.Ambiguities()) != 0 || len(g.Vertices()) != 4 {
+		t.Errorf("a{1,3} should be a chain of 4 vertices, got %s", g)
+	}
+	panics := func(f func()) (panicked bool) {
+		defer func() { panicked = recover() != nil }()
+		f()
+		return
+	}
+	if !panics(func() { Repeat(Terminal(&m, "a"), 0, MaxRepeat+1) }) {
+		t.Errorf("a{0,%d} should panic", MaxRepeat+1)
+	}
+	// every repetition is within MaxRepeat, not their product
+	a := Repeat(Terminal(&m, "a"), MaxRepeat, MaxRepeat)
+	if len(a.Vertices()) != MaxRepeat+1 {
+		t.Errorf("expected %d vertices, got %d", MaxRepeat+1, len(a.Vertices()))
+	}
+	if !panics(func() { Repeat(a, 0, MaxRepeat) }) {
+		t.Errorf("(a{%d}){0,%d} should panic", MaxRepeat, MaxRepeat)
+	}
+}
+
+func TestReadXSD(t *testing.T) {
+	var m StringManager
+	elements, err := ReadXSD(&m, strings.NewReader(testXSD))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"book", "person", "name", "alias", "telephone", "email", "street", "city", "zip"} {
+		if elements[name] == nil {
+			t.Errorf("missing element %s", name)
+		}
+	}
+	person := elements["person"]
+	accepted := []string{
+		"name email",
+		"name alias alias telephone email",
+		"name telephone city street",
+		"name telephone zip street city",
+	}
+	for _, s := range accepted {
+		if !person.Match(strings.Fields(s)) {
+			t.Errorf("%s should be accepted", s)
+		}
+	}
+	rejected := []string{
+		"name",
+		"name alias alias alias email",
+		"name email street",
+		"name email street city street",
+	}
+	for _, s := range rejected {
+		if person.Match(strings.Fields(s)) {
+			t.Errorf("%s should be rejected", s)
+		}
+	}
+	if !elements["book"].Match(nil) || !elements["name"].Match(nil) {
+		t.Errorf("book and name should accept empty content")
+	}
+
+	// maxOccurs="0" excludes the element from xs:all
+	elements, err = ReadXSD(&m, strings.NewReader(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+		<xs:element name="a"><xs:complexType><xs:all>
+			<xs:element name="b"/>
+			<xs:element name="c" minOccurs="0" maxOccurs="0"/>
+		</xs:all></xs:complexType></xs:element></xs:schema>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := elements["a"]; !a.Match([]string{"b"}) || a.Match([]string{"b", "c"}) || a.Match([]string{"c", "b"}) {
+		t.Errorf("c should be excluded, got %s", a)
+	}
+}
+
+func TestReadXSDErrors(t *testing.T) {
+	schemas := map[string]string{
+		"occurrences": `<xs:element name="a"><xs:complexType><xs:sequence>
+			<xs:element name="b" maxOccurs="100000"/>
+		</xs:sequence></xs:complexType></xs:element>`,
+		"nested occurrences": `<xs:element name="a"><xs:complexType><xs:sequence maxOccurs="1000">
+			<xs:element name="b" maxOccurs="1000"/>
+		</xs:sequence></xs:complexType></xs:element>`,
+		"repeated in xs:all": `<xs:element name="a"><xs:complexType><xs:all>
+			<xs:element name="b" maxOccurs="2"/>
+		</xs:all></xs:complexType></xs:element>`,
+		"circular groups": `<xs:group name="g"><xs:sequence><xs:group ref="h"/></xs:sequence></xs:group>
+		<xs:group name="h"><xs:sequence><xs:element name="b"/><xs:group ref="g" minOccurs="0"/></xs:sequence></xs:group>
+		<xs:element name="a"><xs:complexType><xs:group ref="g"/></xs:complexType></xs:element>`,
+	}
+	for name, schema := range schemas {
+		var m StringManager
+		_, err := ReadXSD(&m, strings.NewReader(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">`+schema+`</xs:schema>`))
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"ericaro.net/gogrex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// grex render -dir renders every expression file of a directory tree into another one, keeping the same relative
+// paths: schemas/a/b.grex becomes docs/a/b.svg. Files are rendered in parallel, and every error is reported at the end.
+
+//grexExt is the extension of expression files
+const grexExt = ".grex"
+
+//extensions of the rendered files, by format
+var extensions = map[string]string{
+	"mermaid":  "mmd",
+	"plantuml": "puml",
+}
+
+//renderDir renders every expression file of dir into out
+func renderDir(dir, out, format string, goRegexp bool, write func(*gogrex.Grex, io.Writer) error) int {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && filepath.Ext(path) == grexExt {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil {
+		return fail("%s", err)
+	}
+	ext, ok := extensions[format]
+	if !ok {
+		ext = format
+	}
+
+	errs := make([]error, len(files))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				rel, err := filepath.Rel(dir, files[i])
+				if err == nil {
+					target := filepath.Join(out, strings.TrimSuffix(rel, grexExt)+"."+ext)
+					err = renderFile(files[i], target, goRegexp, write)
+				}
+				errs[i] = err
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var failures []string
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", files[i], err))
+		}
+	}
+	sort.Strings(failures)
+	for _, f := range failures {
+		fmt.Fprintf(os.Stderr, "%s\n", f)
+	}
+	fmt.Fprintf(os.Stderr, "%d files rendered, %d errors\n", len(files)-len(failures), len(failures))
+	if len(failures) > 0 {
+		return exitError
+	}
+	return exitOK
+}
+
+//renderFile renders a single expression file. Every file has its own Manager, they are not safe for concurrent use.
+func renderFile(path, target string, goRegexp bool, write func(*gogrex.Grex, io.Writer) error) error {
+	s := source{file: path, goRegexp: goRegexp}
+	var m gogrex.StringManager
+	g, err := s.grex(&m)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	if err := write(g, &b); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return writeFile(target, b.Bytes())
+}
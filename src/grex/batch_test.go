@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"a.grex":       "a, b*",
+		"sub/b.grex":   "(a|b)+",
+		"sub/c/c.grex": "a?",
+		"sub/bad.grex": "a, (b",
+		"readme.txt":   "not an expression",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, "in", name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	in, out := filepath.Join(dir, "in"), filepath.Join(dir, "out")
+
+	code, _, stderr := run(t, "", "render", "-dir", in, "-out", out, "-format", "dot")
+	if code != exitError {
+		t.Errorf("expected %d for the bad file, got %d", exitError, code)
+	}
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], filepath.Join(in, "sub/bad.grex")+": ") || lines[1] != "3 files rendered, 1 errors" {
+		t.Errorf("unexpected errors %q", stderr)
+	}
+	for _, name := range []string{"a.dot", "sub/b.dot", "sub/c/c.dot"} {
+		if b, err := ioutil.ReadFile(filepath.Join(out, name)); err != nil || !strings.HasPrefix(string(b), "digraph") {
+			t.Errorf("%s: unexpected output %q %v", name, b, err)
+		}
+	}
+	for _, name := range []string{"sub/bad.dot", "readme.dot"} {
+		if _, err := os.Stat(filepath.Join(out, name)); !os.IsNotExist(err) {
+			t.Errorf("%s should not be rendered", name)
+		}
+	}
+
+	// the extension follows the format, and the output defaults to the input directory
+	os.Remove(filepath.Join(in, "sub/bad.grex"))
+	if code, _, stderr := run(t, "", "render", "-dir", in, "-format", "mermaid"); code != exitOK || strings.TrimSpace(stderr) != "3 files rendered, 0 errors" {
+		t.Errorf("unexpected result %d %q", code, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(in, "sub/c/c.mmd")); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"ericaro.net/gogrex"
+	"fmt"
+)
+
+//check reports the ambiguities of the expression
+func check(args []string) int {
+	var s source
+	fs := s.flags("check", "")
+	fs.Parse(args)
+	var m gogrex.StringManager
+	g, err := s.grex(&m)
+	if err != nil {
+		return fail("%s: %s", s.name(), err)
+	}
+	ambiguities := g.Ambiguities()
+	for _, a := range ambiguities {
+		fmt.Printf("%s: %s\n", s.name(), a)
+	}
+	if len(ambiguities) > 0 {
+		return exitFailed
+	}
+	return exitOK
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"ericaro.net/gogrex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+//format prints the canonical form of the expression, as given by gogrex.Expr
+func format(args []string) int {
+	var s source
+	fs := s.flags("fmt", "")
+	write := fs.Bool("w", false, "write the result to the -f file instead of stdout")
+	fs.Parse(args)
+	if s.goRegexp {
+		return fail("fmt does not support Go regexps")
+	}
+	if *write && s.file == "" {
+		return fail("-w requires -f")
+	}
+	exp, err := s.read()
+	if err != nil {
+		return fail("%s", err)
+	}
+	e, err := gogrex.ParseExpr(exp)
+	if err != nil {
+		return fail("%s: %s", s.name(), err)
+	}
+	if *write {
+		// symbols cannot contain '/': it starts a comment, that the canonical form would lose
+		if strings.Contains(exp, "/") {
+			return fail("%s: the expression has comments, they would be lost by -w", s.name())
+		}
+		if err := ioutil.WriteFile(s.file, []byte(e.String()+"\n"), 0644); err != nil {
+			return fail("%s", err)
+		}
+		return exitOK
+	}
+	fmt.Println(e.String())
+	return exitOK
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"ericaro.net/gogrex"
+	"fmt"
+	"strings"
+)
+
+//gen prints the accepted sequences, shortest first, one per line
+func gen(args []string) int {
+	var s source
+	fs := s.flags("gen", "")
+	length := fs.Int("n", 5, "maximum length of the sequences")
+	limit := fs.Int("limit", 100, "maximum number of sequences, negative for no limit")
+	fs.Parse(args)
+	var m gogrex.StringManager
+	g, err := s.grex(&m)
+	if err != nil {
+		return fail("%s: %s", s.name(), err)
+	}
+	for _, sequence := range g.Sequences(*length, *limit) {
+		fmt.Println(strings.Join(sequence, " "))
+	}
+	return exitOK
+}
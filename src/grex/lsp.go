@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// grex lsp is a language server for grammar files, speaking JSON-RPC over stdin and stdout, with Content-Length
+// framing. Documents are fully synchronized on every change. It provides diagnostics, hover (first and follow
+// symbols), go to definition of rules, formatting with the canonical printer, and completion of known names.
+
+//message is a JSON-RPC request or notification
+type message struct {
+	ID     *json.RawMessage `json:"id"`
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+}
+
+//response is a JSON-RPC response
+type response struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  interface{}      `json:"result"`
+}
+
+//errorResponse is a JSON-RPC response with an error
+type errorResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Error   rpcError         `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+//notification is sent by the server
+type notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// JSON-RPC and LSP error codes
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInvalidRequest = -32600
+)
+
+// protocol types
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type rng struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type location struct {
+	URI   string `json:"uri"`
+	Range rng    `json:"range"`
+}
+
+const (
+	severityError   = 1
+	severityWarning = 2
+
+	completionFunction = 3
+	completionValue    = 12
+)
+
+type diagnostic struct {
+	Range    rng    `json:"range"`
+	Severity int    `json:"severity"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+type markup struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type hover struct {
+	Contents markup `json:"contents"`
+	Range    *rng   `json:"range,omitempty"`
+}
+
+type textEdit struct {
+	Range   rng    `json:"range"`
+	NewText string `json:"newText"`
+}
+
+type completionItem struct {
+	Label string `json:"label"`
+	Kind  int    `json:"kind"`
+}
+
+type textDocumentPosition struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position position `json:"position"`
+}
+
+type didOpenParams struct {
+	TextDocument struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	} `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+//maxMessage is the maximum size of a message body
+const maxMessage = 64 << 20
+
+//languageServer holds the open documents
+type languageServer struct {
+	in        *bufio.Reader
+	out       io.Writer
+	documents map[string]*document
+	shutdown  bool
+}
+
+func lsp(args []string) int {
+	s := &languageServer{in: bufio.NewReader(os.Stdin), out: os.Stdout, documents: make(map[string]*document)}
+	return s.serve()
+}
+
+//serve reads and handles the messages, until exit
+func (s *languageServer) serve() int {
+	for {
+		body, err := s.read()
+		if err == io.EOF {
+			return exitFailed // the client left without exit
+		}
+		if err != nil {
+			return fail("lsp: %s", err)
+		}
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			s.reply(nil, nil, &rpcError{codeParseError, err.Error()})
+			continue
+		}
+		if msg.Method == "exit" {
+			if s.shutdown {
+				return exitOK
+			}
+			return exitFailed
+		}
+		result, rerr := s.handle(msg)
+		if msg.ID != nil {
+			s.reply(msg.ID, result, rerr)
+		}
+	}
+}
+
+//read reads the body of the next message
+func (s *languageServer) read() ([]byte, error) {
+	length := -1
+	for {
+		line, err := s.in.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		if i := strings.IndexByte(line, ':'); i >= 0 && strings.EqualFold(strings.TrimSpace(line[:i]), "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(line[i+1:])); err != nil {
+				return nil, errors.New(fmt.Sprintf("invalid Content-Length %q", line[i+1:]))
+			}
+		}
+	}
+	if length < 0 {
+		return nil, errors.New("missing Content-Length")
+	}
+	if length > maxMessage {
+		return nil, errors.New(fmt.Sprintf("Content-Length %d is larger than %d", length, maxMessage))
+	}
+	body := make([]byte, length)
+	_, err := io.ReadFull(s.in, body)
+	return body, err
+}
+
+//write sends a message
+func (s *languageServer) write(v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(err) // every message is made of plain types
+	}
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func (s *languageServer) reply(id *json.RawMessage, result interface{}, err *rpcError) {
+	if err != nil {
+		s.write(errorResponse{"2.0", id, *err})
+		return
+	}
+	s.write(response{"2.0", id, result})
+}
+
+//handle runs a request or a notification
+func (s *languageServer) handle(msg message) (interface{}, *rpcError) {
+	if s.shutdown && msg.Method != "exit" {
+		return nil, &rpcError{codeInvalidRequest, "the server is shut down"}
+	}
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":           1, // full
+				"hoverProvider":              true,
+				"definitionProvider":         true,
+				"documentFormattingProvider": true,
+				"completionProvider":         map[string]interface{}{},
+			},
+			"serverInfo": map[string]string{"name": "grex"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, &rpcError{codeInvalidParams, err.Error()}
+		}
+		s.update(p.TextDocument.URI, p.TextDocument.Text)
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, &rpcError{codeInvalidParams, err.Error()}
+		}
+		if n := len(p.ContentChanges); n > 0 {
+			s.update(p.TextDocument.URI, p.ContentChanges[n-1].Text)
+		}
+	case "textDocument/didClose":
+		var p textDocumentPosition
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, &rpcError{codeInvalidParams, err.Error()}
+		}
+		delete(s.documents, p.TextDocument.URI)
+		s.write(notification{"2.0", "textDocument/publishDiagnostics", map[string]interface{}{"uri": p.TextDocument.URI, "diagnostics": []diagnostic{}}})
+	case "textDocument/hover", "textDocument/definition", "textDocument/formatting", "textDocument/completion":
+		var p textDocumentPosition
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, &rpcError{codeInvalidParams, err.Error()}
+		}
+		d, ok := s.documents[p.TextDocument.URI]
+		if !ok {
+			return nil, &rpcError{codeInvalidParams, "unknown document " + p.TextDocument.URI}
+		}
+		offset := d.offset(p.Position)
+		switch msg.Method {
+		case "textDocument/hover":
+			if h := d.hover(offset); h != nil {
+				return h, nil
+			}
+		case "textDocument/definition":
+			if rg := d.definition(offset); rg != nil {
+				return location{p.TextDocument.URI, *rg}, nil
+			}
+		case "textDocument/formatting":
+			return d.format(), nil
+		case "textDocument/completion":
+			return d.completion(), nil
+		}
+	default:
+		if msg.ID != nil {
+			return nil, &rpcError{codeMethodNotFound, "unsupported method " + msg.Method}
+		}
+	}
+	return nil, nil
+}
+
+//update replaces the text of a document, and publishes its diagnostics
+func (s *languageServer) update(uri, text string) {
+	d := newDocument(text)
+	s.documents[uri] = d
+	s.write(notification{"2.0", "textDocument/publishDiagnostics", map[string]interface{}{"uri": uri, "diagnostics": d.diagnostics()}})
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+//frame encodes a message with its Content-Length header
+func frame(body string) string {
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func TestLSPRead(t *testing.T) {
+	s := &languageServer{in: bufio.NewReader(strings.NewReader(
+		frame(`{"a":1}`) + "content-length: 2\r\nContent-Type: x\r\n\r\n{}" + "\r\n" + "Content-Length: x\r\n\r\n"))}
+	for _, want := range []string{`{"a":1}`, `{}`} {
+		if body, err := s.read(); err != nil || string(body) != want {
+			t.Errorf("expected %s, got %s %v", want, body, err)
+		}
+	}
+	for _, bad := range []string{"\r\n", "Content-Length: x\r\n\r\n", fmt.Sprintf("Content-Length: %d\r\n\r\n", maxMessage+1)} {
+		s := &languageServer{in: bufio.NewReader(strings.NewReader(bad))}
+		if _, err := s.read(); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestLSPPosition(t *testing.T) {
+	d := newDocument("a = é, 😀\nb = a")
+	cases := []struct {
+		offset int
+		pos    position
+	}{
+		{0, position{0, 0}},
+		{6, position{0, 5}},  // after é, 2 bytes but 1 unit
+		{12, position{0, 9}}, // after 😀, 4 bytes but 2 units
+		{13, position{1, 0}},
+		{18, position{1, 5}},
+	}
+	for _, c := range cases {
+		if got := d.position(c.offset); got != c.pos {
+			t.Errorf("offset %d: expected %v got %v", c.offset, c.pos, got)
+		}
+		if got := d.offset(c.pos); got != c.offset {
+			t.Errorf("%v: expected offset %d got %d", c.pos, c.offset, got)
+		}
+	}
+	// out of the document
+	for pos, want := range map[position]int{{-1, 3}: 0, {0, -4}: 0, {1, -1}: 13, {0, 100}: 12, {7, 0}: 18} {
+		if got := d.offset(pos); got != want {
+			t.Errorf("%v: expected offset %d got %d", pos, want, got)
+		}
+	}
+}
+
+func TestLSPSession(t *testing.T) {
+	var in strings.Builder
+	for _, msg := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///a.grex","text":"a = b, c\nb = x y"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///a.grex"},"position":{"line":0,"character":4}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"textDocument/definition","params":{"textDocument":{"uri":"file:///a.grex"},"position":{"line":-1,"character":-1}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"unknown"}`,
+		`{"jsonrpc":"2.0","id":5,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	} {
+		in.WriteString(frame(msg))
+	}
+	var out bytes.Buffer
+	s := &languageServer{in: bufio.NewReader(strings.NewReader(in.String())), out: &out, documents: make(map[string]*document)}
+	if code := s.serve(); code != exitOK {
+		t.Errorf("expected a clean exit, got %d", code)
+	}
+
+	r := &languageServer{in: bufio.NewReader(&out)}
+	var messages []map[string]interface{}
+	for {
+		body, err := r.read()
+		if err != nil {
+			break
+		}
+		var msg map[string]interface{}
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, msg)
+	}
+	if len(messages) != 6 {
+		t.Fatalf("expected 6 messages, got %v", messages)
+	}
+	if caps, _ := messages[0]["result"].(map[string]interface{}); caps["capabilities"] == nil {
+		t.Errorf("unexpected initialize result %v", messages[0])
+	}
+	params, _ := messages[1]["params"].(map[string]interface{})
+	diagnostics, _ := params["diagnostics"].([]interface{})
+	if messages[1]["method"] != "textDocument/publishDiagnostics" || len(diagnostics) != 1 ||
+		diagnostics[0].(map[string]interface{})["message"] != "missing operator" {
+		t.Errorf("unexpected diagnostics %v", messages[1])
+	}
+	hover, _ := messages[2]["result"].(map[string]interface{})
+	if contents, _ := hover["contents"].(map[string]interface{}); contents == nil || !strings.Contains(contents["value"].(string), "follow: c") {
+		t.Errorf("unexpected hover %v", messages[2])
+	}
+	if messages[3]["id"] != 3.0 || messages[3]["error"] != nil {
+		t.Errorf("a negative position should not fail %v", messages[3])
+	}
+	if e, _ := messages[4]["error"].(map[string]interface{}); e == nil || e["code"] != float64(codeMethodNotFound) {
+		t.Errorf("expected method not found, got %v", messages[4])
+	}
+	if messages[5]["id"] != 5.0 {
+		t.Errorf("unexpected shutdown response %v", messages[5])
+	}
+}
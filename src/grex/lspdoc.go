@@ -0,0 +1,247 @@
+package main
+
+import (
+	"ericaro.net/gogrex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// A grammar file is a list of rules "name = expression", an expression can span several lines, up to the next rule.
+// A file without any rule is a single expression. Comments are allowed anywhere an expression allows them.
+
+var (
+	ruleStart  = regexp.MustCompile(`(?m)^[ \t]*([\pL_][\pL\pN_]*)[ \t]*=`)
+	identifier = regexp.MustCompile(`[\pL_][\pL\pN_]*`)
+)
+
+//rule is a named expression of a document
+type rule struct {
+	name       string
+	nameOffset int // byte offset of the name, -1 for the expression of a file without rules
+	start, end int // byte range of the expression
+	expr       *gogrex.Expr
+	err        error
+}
+
+//document is an open grammar file
+type document struct {
+	text  string
+	lines []int // byte offset of the start of every line
+	rules []rule
+	junk  int // byte offset of text before the first rule, -1 if there is none
+}
+
+func newDocument(text string) *document {
+	d := &document{text: text, lines: []int{0}, junk: -1}
+	for i, c := range text {
+		if c == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+	matches := ruleStart.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		d.rules = []rule{{nameOffset: -1, start: 0, end: len(text)}}
+	} else {
+		// only comments are allowed before the first rule: it is an empty expression
+		if _, err := gogrex.ParseExpr(text[:matches[0][0]]); err != nil && !isEmpty(err) {
+			d.junk = len(text[:matches[0][0]]) - len(strings.TrimLeftFunc(text[:matches[0][0]], unicode.IsSpace))
+		}
+		for i, m := range matches {
+			end := len(text)
+			if i+1 < len(matches) {
+				end = matches[i+1][0]
+			}
+			d.rules = append(d.rules, rule{name: text[m[2]:m[3]], nameOffset: m[2], start: m[1], end: end})
+		}
+	}
+	for i := range d.rules {
+		r := &d.rules[i]
+		r.expr, r.err = gogrex.ParseExpr(text[r.start:r.end])
+	}
+	return d
+}
+
+//isEmpty tells if the error is about an empty expression
+func isEmpty(err error) bool {
+	perr, ok := err.(*gogrex.ParseError)
+	return ok && perr.Msg == "empty expression"
+}
+
+//position converts a byte offset into a protocol position, in UTF-16 code units
+func (d *document) position(offset int) position {
+	line := sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > offset }) - 1
+	return position{line, len(utf16.Encode([]rune(d.text[d.lines[line]:offset])))}
+}
+
+//offset converts a protocol position into a byte offset. Positions out of the document are clamped to it.
+func (d *document) offset(p position) int {
+	if p.Line < 0 {
+		return 0
+	}
+	if p.Line >= len(d.lines) {
+		return len(d.text)
+	}
+	offset := d.lines[p.Line]
+	for units := 0; units < p.Character && offset < len(d.text) && d.text[offset] != '\n'; {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		units += len(utf16.Encode([]rune{r}))
+		offset += size
+	}
+	return offset
+}
+
+//span returns the range of the bytes [start, end)
+func (d *document) span(start, end int) rng {
+	return rng{d.position(start), d.position(end)}
+}
+
+//token returns the end of the identifier or character at offset
+func (d *document) token(offset int) int {
+	if offset >= len(d.text) {
+		return offset
+	}
+	if loc := identifier.FindStringIndex(d.text[offset:]); loc != nil && loc[0] == 0 {
+		return offset + loc[1]
+	}
+	_, size := utf8.DecodeRuneInString(d.text[offset:])
+	return offset + size
+}
+
+//word returns the identifier around the offset, "" if there is none
+func (d *document) word(offset int) string {
+	for _, loc := range identifier.FindAllStringIndex(d.text, -1) {
+		if loc[0] <= offset && offset <= loc[1] {
+			return d.text[loc[0]:loc[1]]
+		}
+	}
+	return ""
+}
+
+//ruleAt returns the rule whose expression contains the offset, nil if there is none
+func (d *document) ruleAt(offset int) *rule {
+	for i := range d.rules {
+		if r := &d.rules[i]; r.start <= offset && offset <= r.end {
+			return r
+		}
+	}
+	return nil
+}
+
+func (d *document) diagnostics() []diagnostic {
+	diagnostics := []diagnostic{}
+	if d.junk >= 0 {
+		diagnostics = append(diagnostics, diagnostic{d.span(d.junk, d.token(d.junk)), severityError, "grex", "expected a rule: name = expression"})
+	}
+	for _, r := range d.rules {
+		if r.err != nil {
+			start, msg := r.start, r.err.Error()
+			if perr, ok := r.err.(*gogrex.ParseError); ok {
+				start, msg = r.start+perr.Pos, perr.Msg
+			}
+			diagnostics = append(diagnostics, diagnostic{d.span(start, d.token(start)), severityError, "grex", msg})
+			continue
+		}
+		var m gogrex.StringManager
+		for _, a := range r.expr.Grex(&m).Ambiguities() {
+			for _, s := range a.Spans {
+				diagnostics = append(diagnostics, diagnostic{d.span(r.start+s.Start, r.start+s.End), severityWarning, "grex",
+					fmt.Sprintf("ambiguous %q: it can be matched by %d occurrences", a.Name, len(a.Spans))})
+			}
+		}
+	}
+	return diagnostics
+}
+
+//hover describes the innermost sub expression at the offset
+func (d *document) hover(offset int) *hover {
+	r := d.ruleAt(offset)
+	if r == nil || r.expr == nil {
+		return nil
+	}
+	local := offset - r.start
+	var sub *gogrex.Expr
+	for e := r.expr; e != nil; {
+		if e.Span.Start > local || local > e.Span.End {
+			break
+		}
+		sub = e
+		var inner *gogrex.Expr
+		for _, s := range e.Subs {
+			if s.Span.Start <= local && local <= s.Span.End {
+				inner = s
+			}
+		}
+		e = inner
+	}
+	if sub == nil {
+		return nil
+	}
+	follow, end := r.expr.Follow(sub)
+	if end {
+		follow = append(follow, "(end)")
+	}
+	text := fmt.Sprintf("`%s`\n\nfirst: %s\n\nfollow: %s", sub, strings.Join(sub.First(), " "), strings.Join(follow, " "))
+	if sub.Nullable() {
+		text += "\n\nmatches the empty sequence"
+	}
+	rg := d.span(r.start+sub.Span.Start, r.start+sub.Span.End)
+	return &hover{markup{"markdown", text}, &rg}
+}
+
+//definition returns the range of the name of the rule used at the offset
+func (d *document) definition(offset int) *rng {
+	name := d.word(offset)
+	for _, r := range d.rules {
+		if name != "" && r.name == name {
+			rg := d.span(r.nameOffset, r.nameOffset+len(name))
+			return &rg
+		}
+	}
+	return nil
+}
+
+//format rewrites the valid expressions in their canonical form. Expressions with comments are left as they are.
+func (d *document) format() []textEdit {
+	edits := []textEdit{}
+	for _, r := range d.rules {
+		text := d.text[r.start:r.end]
+		if r.expr == nil || strings.Contains(text, "//") || strings.Contains(text, "/*") {
+			continue
+		}
+		trimmed := strings.TrimSpace(text)
+		start := r.start + strings.Index(text, trimmed)
+		canonical := r.expr.String()
+		if r.nameOffset >= 0 {
+			start, canonical = r.start, " "+canonical
+		}
+		end := r.start + len(strings.TrimRightFunc(text, unicode.IsSpace))
+		if d.text[start:end] != canonical {
+			edits = append(edits, textEdit{d.span(start, end), canonical})
+		}
+	}
+	return edits
+}
+
+//completion returns every rule name and symbol of the document
+func (d *document) completion() []completionItem {
+	kinds := make(map[string]int)
+	for _, loc := range identifier.FindAllStringIndex(d.text, -1) {
+		kinds[d.text[loc[0]:loc[1]]] = completionValue
+	}
+	for _, r := range d.rules {
+		if r.nameOffset >= 0 {
+			kinds[r.name] = completionFunction
+		}
+	}
+	items := []completionItem{}
+	for name, kind := range kinds {
+		items = append(items, completionItem{name, kind})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
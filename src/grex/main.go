@@ -2,34 +2,138 @@ package main
 
 import (
 	"ericaro.net/gogrex"
+	"errors"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/exec"
+	"sort"
 )
-// small util that turn a regexp into a graphiz .dot file and then into a png
+
+// grex is a small tool around gogrex expressions:
+//
+//	grex render [-o file] [-format svg|dot|png|json|graphml|gexf|mermaid|plantuml]   draw the graph
+//	grex render -dir dir [-out dir] [-format ...]                                   draw every .grex file of a directory
+//	grex match [-sep line|space|csv] [file]                                         match sequences of symbols
+//	grex fmt [-w]                                                                   print the canonical form
+//	grex check                                                                      report ambiguities
+//	grex gen [-n length] [-limit count]                                             list accepted sequences
+//	grex repl [-history file]                                                       explore expressions interactively
+//	grex serve [-addr host:port]                                                    run the web playground
+//	grex lsp                                                                        language server, over stdio
+//
+// Every command but repl, serve and lsp reads the expression from -e, from the file given by -f, or from stdin.
+
+// exit codes
+const (
+	exitOK     = 0
+	exitFailed = 1 // the command ran, but the answer is negative: no match, ambiguities...
+	exitError  = 2 // invalid usage, unreadable or invalid expression
+)
+
+//command is a subcommand of grex
+type command struct {
+	usage string
+	run   func(args []string) int
+}
+
+var commands = map[string]command{
+	"render": {"draw the graph of the expression", render},
+	"match":  {"match the sequences of symbols of a file", match},
+	"fmt":    {"print the expression in canonical form", format},
+	"check":  {"report the ambiguous symbols of the expression", check},
+	"gen":    {"list the sequences accepted by the expression", gen},
+	"repl":   {"explore expressions interactively", repl},
+	"serve":  {"run a web playground", serve},
+	"lsp":    {"run the language server, over stdin and stdout", lsp},
+}
+
 func main() {
-	exp := os.Args[1]
-	fmt.Printf("Parsing %s\n", exp)
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(exitError)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "grex: unknown command %q\n", flag.Arg(0))
+		usage()
+		os.Exit(exitError)
+	}
+	os.Exit(cmd.run(flag.Args()[1:]))
+}
 
-	var m gogrex.StringManager
-	g, err := gogrex.ParseGrex(&m, exp)
-	if err != nil {
-		fmt.Printf("error %s", err)
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: grex command [flags] [args]\n\ncommands:\n")
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", name, commands[name].usage)
 	}
+	fmt.Fprintf(os.Stderr, "\nrun 'grex command -h' for the flags of a command\n")
+}
 
-	fmt.Printf("\n%s\n", g.String())
-	err = ioutil.WriteFile("graph.dot", []byte(g.String()), 0644)
-	if err != nil {
-		panic(err)
+//source is where a command reads its expression from
+type source struct {
+	expr     string
+	file     string
+	goRegexp bool
+}
+
+//flags creates the flag set of a command, with the flags of the source
+func (s *source) flags(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet("grex "+name, flag.ExitOnError)
+	fs.StringVar(&s.expr, "e", "", "the expression")
+	fs.StringVar(&s.file, "f", "", "read the expression from a file (default stdin)")
+	fs.BoolVar(&s.goRegexp, "re", false, "parse the expression as a Go regexp over runes")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: grex %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
 	}
-	dot("graph.dot")
+	return fs
 }
 
-func dot(dot string) error{
+//read returns the expression
+func (s *source) read() (string, error) {
+	switch {
+	case s.expr != "" && s.file != "":
+		return "", errors.New("-e and -f are exclusive")
+	case s.expr != "":
+		return s.expr, nil
+	case s.file != "":
+		b, err := ioutil.ReadFile(s.file)
+		return string(b), err
+	}
+	b, err := ioutil.ReadAll(os.Stdin)
+	return string(b), err
+}
+
+//name returns the name of the source, for messages
+func (s *source) name() string {
+	if s.expr == "" && s.file != "" {
+		return s.file
+	}
+	return "expression"
+}
+
+//grex reads and parses the expression
+func (s *source) grex(m gogrex.Manager) (*gogrex.Grex, error) {
+	exp, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	if s.goRegexp {
+		return gogrex.ParseRegexp(m, exp)
+	}
+	return gogrex.ParseGrex(m, exp)
+}
 
-	cmd := exec.Command("dot", "-Tpng", "-ograph.png", dot)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+//fail prints an error, and returns the error exit code
+func fail(format string, args ...interface{}) int {
+	fmt.Fprintf(os.Stderr, "grex: "+format+"\n", args...)
+	return exitError
 }
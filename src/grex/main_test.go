@@ -0,0 +1,98 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//run runs a command with the given stdin, and returns its exit code, stdout and stderr
+func run(t *testing.T, stdin string, args ...string) (code int, stdout, stderr string) {
+	dir, err := ioutil.TempDir("", "grex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := make([]*os.File, 3)
+	for i, name := range []string{"stdin", "stdout", "stderr"} {
+		if files[i], err = os.Create(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+		defer files[i].Close()
+	}
+	files[0].WriteString(stdin)
+	files[0].Seek(0, 0)
+	in, out, errs := os.Stdin, os.Stdout, os.Stderr
+	os.Stdin, os.Stdout, os.Stderr = files[0], files[1], files[2]
+	defer func() { os.Stdin, os.Stdout, os.Stderr = in, out, errs }()
+
+	code = commands[args[0]].run(args[1:])
+	o, _ := ioutil.ReadFile(files[1].Name())
+	e, _ := ioutil.ReadFile(files[2].Name())
+	return code, string(o), string(e)
+}
+
+func TestCommands(t *testing.T) {
+	cases := []struct {
+		stdin  string
+		args   []string
+		code   int
+		stdout string // expected prefix of the output
+	}{
+		{"a , (b|c)*", []string{"fmt"}, exitOK, "a, (b | c)*\n"},
+		{"", []string{"fmt", "-e", "a b"}, exitError, ""},
+		{"", []string{"fmt", "-w", "-e", "a"}, exitError, ""},
+		{"", []string{"check", "-e", "a, b"}, exitOK, ""},
+		{"", []string{"check", "-e", "a*, a"}, exitFailed, "expression: "},
+		{"", []string{"check", "-e", "a)"}, exitError, ""},
+		{"", []string{"gen", "-e", "a, b?", "-n", "3"}, exitOK, "a\na b\n"},
+		{"", []string{"gen", "-e", "a*", "-n", "2", "-limit", "2"}, exitOK, "\na\n"},
+		{"", []string{"gen", "-e", "("}, exitError, ""},
+		{"a, b", []string{"render", "-format", "dot"}, exitOK, "digraph {"},
+		{"", []string{"render", "-format", "mermaid", "-e", "a"}, exitOK, "stateDiagram-v2"},
+		{"", []string{"render", "-format", "svg", "-e", "a"}, exitOK, "<?xml"},
+		{"", []string{"render", "-format", "nope", "-e", "a"}, exitError, ""},
+		{"", []string{"render", "-e", "a,"}, exitError, ""},
+		{"", []string{"render", "-dir", ".", "-e", "a"}, exitError, ""},
+	}
+	for _, c := range cases {
+		code, stdout, stderr := run(t, c.stdin, c.args...)
+		if code != c.code {
+			t.Errorf("%v: expected exit code %d, got %d %s", c.args, c.code, code, stderr)
+		}
+		if !strings.HasPrefix(stdout, c.stdout) {
+			t.Errorf("%v: unexpected output %q", c.args, stdout)
+		}
+		if code == exitError && (stdout != "" || !strings.HasPrefix(stderr, "grex: ")) {
+			t.Errorf("%v: unexpected error %q, with output %q", c.args, stderr, stdout)
+		}
+	}
+}
+
+func TestFormatWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "a.grex")
+	ioutil.WriteFile(file, []byte("a ,b*"), 0644)
+	if code, _, stderr := run(t, "", "fmt", "-w", "-f", file); code != exitOK {
+		t.Fatalf("fmt -w failed: %s", stderr)
+	}
+	if b, _ := ioutil.ReadFile(file); string(b) != "a, b*\n" {
+		t.Errorf("unexpected formatted file %q", b)
+	}
+
+	// comments would be lost
+	commented := "a, // the a\nb* /* any b */"
+	ioutil.WriteFile(file, []byte(commented), 0644)
+	if code, _, _ := run(t, "", "fmt", "-w", "-f", file); code != exitError {
+		t.Errorf("fmt -w should refuse to drop comments")
+	}
+	if b, _ := ioutil.ReadFile(file); string(b) != commented {
+		t.Errorf("the file was changed %q", b)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"ericaro.net/gogrex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// grex match reads sequences of symbols from a file (or stdin), and matches every one of them. Records are separated
+// by blank lines. Within a record, symbols are one per line, or separated by spaces, or by commas (with CSV quoting).
+
+//splitters split a line into symbols
+var splitters = map[string]func(line string) ([]string, error){
+	"line": func(line string) ([]string, error) {
+		return []string{strings.TrimSpace(line)}, nil
+	},
+	"space": func(line string) ([]string, error) {
+		return strings.Fields(line), nil
+	},
+	"csv": func(line string) ([]string, error) {
+		r := csv.NewReader(strings.NewReader(line))
+		r.TrimLeadingSpace = true
+		fields, err := r.Read()
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		return fields, err
+	},
+}
+
+//record is a sequence of symbols, with the line of each symbol
+type record struct {
+	symbols []string
+	lines   []int
+	start   int // the first line of the record
+	end     int // the last line of the record
+}
+
+func match(args []string) int {
+	var s source
+	fs := s.flags("match", "[file]")
+	sep := fs.String("sep", "line", "symbol separator: line (one symbol per line), space or csv")
+	fs.Parse(args)
+	split, ok := splitters[*sep]
+	if !ok {
+		return fail("unknown separator %q", *sep)
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return exitError
+	}
+	var in io.Reader = os.Stdin
+	if fs.NArg() == 1 && fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return fail("%s", err)
+		}
+		defer f.Close()
+		in = f
+	} else if s.expr == "" && s.file == "" {
+		return fail("the expression and the sequences cannot both be read from stdin, use -e or -f")
+	}
+
+	var m gogrex.StringManager
+	g, err := s.grex(&m)
+	if err != nil {
+		return fail("%s: %s", s.name(), err)
+	}
+	records, err := readRecords(in, split)
+	if err != nil {
+		return fail("%s", err)
+	}
+	matcher := g.NewMatcher()
+	failed := 0
+	for i, r := range records {
+		prefix := ""
+		if len(records) > 1 {
+			prefix = fmt.Sprintf("record %d: ", i+1)
+		}
+		if msg := matchRecord(matcher, r); msg != "" {
+			failed++
+			fmt.Printf("%s%s\n", prefix, msg)
+		} else {
+			fmt.Printf("%sok\n", prefix)
+		}
+	}
+	if len(records) > 1 {
+		fmt.Printf("%d records: %d ok, %d failed\n", len(records), len(records)-failed, failed)
+	}
+	if failed > 0 {
+		return exitFailed
+	}
+	return exitOK
+}
+
+//matchRecord runs the record through the matcher. It returns an empty string if the record is accepted,
+// a description of the failure otherwise.
+func matchRecord(matcher gogrex.SequenceMatcher, r record) string {
+	matcher.Reset()
+	for i, symbol := range r.symbols {
+		if !matcher.Next(symbol) {
+			return fmt.Sprintf("line %d: unexpected %q, expected %s", r.lines[i], symbol, expected(matcher))
+		}
+	}
+	if !matcher.Accepts() {
+		return fmt.Sprintf("line %d: unexpected end of sequence, expected %s", r.end, expected(matcher))
+	}
+	return ""
+}
+
+//expected describes the symbols expected by the matcher
+func expected(matcher gogrex.SequenceMatcher) string {
+	symbols := matcher.Expected()
+	if len(symbols) == 0 {
+		return "nothing"
+	}
+	return "one of: " + strings.Join(symbols, " ")
+}
+
+//readRecords reads the records separated by blank lines. An input without any symbol is a single empty record.
+func readRecords(in io.Reader, split func(string) ([]string, error)) ([]record, error) {
+	var records []record
+	var current *record
+	reader := bufio.NewReader(in) // not a Scanner: lines have no length limit
+	line := 0
+	for {
+		text, err := reader.ReadString('\n')
+		if err == io.EOF && text == "" {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		line++
+		text = strings.TrimSuffix(strings.TrimSuffix(text, "\n"), "\r")
+		if strings.TrimSpace(text) == "" {
+			current = nil
+			continue
+		}
+		if current == nil {
+			records = append(records, record{start: line})
+			current = &records[len(records)-1]
+		}
+		symbols, err := split(text)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("line %d: %s", line, err))
+		}
+		for _, symbol := range symbols {
+			current.symbols = append(current.symbols, symbol)
+			current.lines = append(current.lines, line)
+		}
+		current.end = line
+	}
+	if len(records) == 0 {
+		records = append(records, record{start: 1, end: line})
+	}
+	return records, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadRecords(t *testing.T) {
+	long := strings.Repeat("a ", 100000) // longer than the default buffer of a bufio.Scanner
+	cases := []struct {
+		sep     string
+		input   string
+		symbols [][]string
+		lines   [][]int
+	}{
+		{"line", "a\n b \n\nc\r\nd", [][]string{{"a", "b"}, {"c", "d"}}, [][]int{{1, 2}, {4, 5}}},
+		{"space", "a b\nc\n\n\n d  e \n", [][]string{{"a", "b", "c"}, {"d", "e"}}, [][]int{{1, 1, 2}, {5, 5}}},
+		{"csv", `a, "b c",d` + "\n\n" + `"e,f"`, [][]string{{"a", "b c", "d"}, {"e,f"}}, [][]int{{1, 1, 1}, {3}}},
+		{"space", "", [][]string{nil}, [][]int{nil}},
+		{"space", long, [][]string{strings.Fields(long)}, nil},
+	}
+	for _, c := range cases {
+		records, err := readRecords(strings.NewReader(c.input), splitters[c.sep])
+		if err != nil {
+			t.Fatalf("%s %q: %v", c.sep, c.input, err)
+		}
+		var symbols [][]string
+		var lines [][]int
+		for _, r := range records {
+			symbols = append(symbols, r.symbols)
+			lines = append(lines, r.lines)
+		}
+		if !reflect.DeepEqual(symbols, c.symbols) {
+			t.Errorf("%s %.20q: expected %v got %.80v", c.sep, c.input, c.symbols, symbols)
+		}
+		if c.lines != nil && !reflect.DeepEqual(lines, c.lines) {
+			t.Errorf("%s %q: expected lines %v got %v", c.sep, c.input, c.lines, lines)
+		}
+	}
+	if _, err := readRecords(strings.NewReader("a\n\"b"), splitters["csv"]); err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("expected an error on line 2, got %v", err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	code, stdout, _ := run(t, "a\nb\n\na\nc\n\na", "match", "-e", "a, b")
+	if code != exitFailed {
+		t.Errorf("expected exit code %d, got %d", exitFailed, code)
+	}
+	expected := `record 1: ok
+record 2: line 5: unexpected "c", expected one of: b
+record 3: line 7: unexpected end of sequence, expected one of: b
+3 records: 1 ok, 2 failed
+`
+	if stdout != expected {
+		t.Errorf("unexpected output\n%s", stdout)
+	}
+	if code, stdout, _ := run(t, "a b", "match", "-sep", "space", "-e", "a, b"); code != exitOK || stdout != "ok\n" {
+		t.Errorf("unexpected result %d %q", code, stdout)
+	}
+	if code, _, _ := run(t, "a", "match"); code != exitError {
+		t.Errorf("the expression and the sequences cannot both come from stdin")
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"ericaro.net/gogrex"
+	"io"
+	"os"
+	"os/exec"
+)
+
+//renderers write a grex in a given format
+var renderers = map[string]func(g *gogrex.Grex, w io.Writer) error{
+	"svg":      (*gogrex.Grex).WriteSVG,
+	"dot":      func(g *gogrex.Grex, w io.Writer) error { return g.WriteDot(w, nil) },
+	"png":      graphviz,
+	"json":     writeJSON,
+	"graphml":  (*gogrex.Grex).WriteGraphML,
+	"gexf":     (*gogrex.Grex).WriteGEXF,
+	"mermaid":  (*gogrex.Grex).WriteMermaid,
+	"plantuml": (*gogrex.Grex).WritePlantUML,
+}
+
+func render(args []string) int {
+	var s source
+	fs := s.flags("render", "")
+	out := fs.String("o", "", "output file (default stdout)")
+	format := fs.String("format", "svg", "svg, dot, png (requires Graphviz), json, graphml, gexf, mermaid or plantuml")
+	dir := fs.String("dir", "", "render every "+grexExt+" file of a directory, and its sub directories")
+	outDir := fs.String("out", "", "output directory of -dir (default the -dir directory)")
+	fs.Parse(args)
+	write, ok := renderers[*format]
+	if !ok {
+		return fail("unknown format %q", *format)
+	}
+	if *dir != "" {
+		if s.expr != "" || s.file != "" || *out != "" {
+			return fail("-dir cannot be used with -e, -f or -o")
+		}
+		if *outDir == "" {
+			*outDir = *dir
+		}
+		return renderDir(*dir, *outDir, *format, s.goRegexp, write)
+	}
+	var m gogrex.StringManager
+	g, err := s.grex(&m)
+	if err != nil {
+		return fail("%s: %s", s.name(), err)
+	}
+	var b bytes.Buffer
+	if err := write(g, &b); err != nil {
+		return fail("%s", err)
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(b.Bytes())
+	} else {
+		err = writeFile(*out, b.Bytes())
+	}
+	if err != nil {
+		return fail("%s", err)
+	}
+	return exitOK
+}
+
+//writeFile writes data to a file
+func writeFile(name string, data []byte) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeJSON(g *gogrex.Grex, w io.Writer) error {
+	b, err := g.MarshalJSON()
+	if err == nil {
+		_, err = w.Write(append(b, '\n'))
+	}
+	return err
+}
+
+//graphviz renders a png with the Graphviz 'dot' command.
+func graphviz(g *gogrex.Grex, w io.Writer) error {
+	cmd := exec.Command("dot", "-Tpng")
+	cmd.Stdin = bytes.NewBufferString(g.String())
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"ericaro.net/gogrex"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// grex repl reads an expression, then sequences of symbols to match against it. Lines starting with ':' are commands,
+// "!n" runs the n-th line of the history again, and "!!" the last one.
+
+const replHelp = `Type an expression, then sequences of symbols (separated by spaces or commas) to match.
+  :e expr            use another expression
+  :match [symbols]   match a sequence, possibly empty
+  :expected [prefix] symbols expected after the prefix
+  :shortest          a shortest accepted sequence
+  :dot               the graph, in dot format
+  :min               the minimal deterministic graph
+  :history           the previous lines, "!n" runs the n-th again, "!!" the last one
+  :help              this help
+  :quit              leave
+`
+
+//session is the state of the repl
+type session struct {
+	out     io.Writer
+	manager gogrex.StringManager
+	expr    *gogrex.Expr
+	grex    *gogrex.Grex
+	history []string
+	file    *os.File // history file, nil if the history is not saved
+}
+
+func repl(args []string) int {
+	fs := flag.NewFlagSet("grex repl", flag.ExitOnError)
+	historyFile := fs.String("history", "", "load and save the history in this file")
+	fs.Parse(args)
+	s := &session{out: os.Stdout}
+	if *historyFile != "" {
+		if data, err := ioutil.ReadFile(*historyFile); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					s.history = append(s.history, line)
+				}
+			}
+		}
+		f, err := os.OpenFile(*historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fail("%s", err)
+		}
+		defer f.Close()
+		s.file = f
+	}
+	fmt.Fprintf(s.out, "%s", "grex repl, :help for help\n")
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Fprintf(s.out, "grex> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(s.out)
+			break
+		}
+		if s.eval(scanner.Text()) {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fail("%s", err)
+	}
+	return exitOK
+}
+
+//eval runs a line, and tells if the session is over
+func (s *session) eval(line string) (quit bool) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "!") {
+		n := len(s.history)
+		if line != "!!" {
+			var err error
+			if n, err = strconv.Atoi(line[1:]); err != nil {
+				fmt.Fprintf(s.out, "invalid history reference %s\n", line)
+				return false
+			}
+		}
+		if n < 1 || n > len(s.history) {
+			fmt.Fprintf(s.out, "no line %d in the history\n", n)
+			return false
+		}
+		line = s.history[n-1]
+		fmt.Fprintf(s.out, "%s\n", line)
+	}
+	if line == "" {
+		return false
+	}
+	if line != ":history" {
+		s.history = append(s.history, line)
+		if s.file != nil {
+			fmt.Fprintln(s.file, line)
+		}
+	}
+
+	if !strings.HasPrefix(line, ":") {
+		if s.grex == nil {
+			s.parse(line)
+		} else {
+			s.match(symbols(line))
+		}
+		return false
+	}
+	cmd, arg := line, ""
+	if i := strings.IndexFunc(line, unicode.IsSpace); i >= 0 {
+		cmd, arg = line[:i], strings.TrimSpace(line[i:])
+	}
+	switch cmd {
+	case ":q", ":quit":
+		return true
+	case ":h", ":help":
+		fmt.Fprintf(s.out, "%s", replHelp)
+	case ":history":
+		for i, h := range s.history {
+			fmt.Fprintf(s.out, "%4d  %s\n", i+1, h)
+		}
+	case ":e", ":expr":
+		s.parse(arg)
+	default:
+		if s.grex == nil {
+			fmt.Fprintf(s.out, "no expression yet\n")
+			return false
+		}
+		switch cmd {
+		case ":match":
+			s.match(symbols(arg))
+		case ":expected":
+			s.expected(symbols(arg))
+		case ":shortest":
+			if shortest := s.grex.ShortestAccepted(); shortest == nil {
+				fmt.Fprintf(s.out, "nothing is accepted\n")
+			} else {
+				fmt.Fprintf(s.out, "%s\n", sequence(shortest))
+			}
+		case ":dot":
+			fmt.Fprintf(s.out, "%s\n", s.grex)
+		case ":min":
+			min := s.grex.Minimize()
+			fmt.Fprintf(s.out, "%s\n%s\n", counts(min), min)
+		default:
+			fmt.Fprintf(s.out, "unknown command %s, :help for help\n", cmd)
+		}
+	}
+	return false
+}
+
+//parse sets the expression, or prints where it is wrong
+func (s *session) parse(exp string) {
+	e, err := gogrex.ParseExpr(exp)
+	if err != nil {
+		fmt.Fprintf(s.out, "%s", caret(exp, err))
+		return
+	}
+	s.expr, s.grex = e, e.Grex(&s.manager)
+	fmt.Fprintf(s.out, "%s: %s\n", counts(s.grex), e)
+}
+
+//match tells if the sequence is accepted, or where it fails
+func (s *session) match(symbols []string) {
+	m := s.grex.NewMatcher()
+	for i, symbol := range symbols {
+		if !m.Next(symbol) {
+			fmt.Fprintf(s.out, "rejected: unexpected %q after %s, expected %s\n", symbol, sequence(symbols[:i]), expected(m))
+			return
+		}
+	}
+	if !m.Accepts() {
+		fmt.Fprintf(s.out, "incomplete: expected %s\n", expected(m))
+		return
+	}
+	fmt.Fprintf(s.out, "accepted\n")
+}
+
+//expected prints the symbols expected after the prefix
+func (s *session) expected(prefix []string) {
+	m := s.grex.NewMatcher()
+	for _, symbol := range prefix {
+		if !m.Next(symbol) {
+			fmt.Fprintf(s.out, "%q is not expected\n", symbol)
+			return
+		}
+	}
+	end := ""
+	if m.Accepts() {
+		end = " (or the end of the sequence)"
+	}
+	fmt.Fprintf(s.out, "%s%s\n", strings.Join(m.Expected(), " "), end)
+}
+
+//symbols splits a sequence on spaces and commas
+func symbols(line string) []string {
+	return strings.FieldsFunc(line, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+}
+
+//sequence prints a sequence of symbols
+func sequence(symbols []string) string {
+	if len(symbols) == 0 {
+		return "the empty sequence"
+	}
+	return strings.Join(symbols, " ")
+}
+
+//counts describes the size of the graph
+func counts(g *gogrex.Grex) string {
+	return fmt.Sprintf("%d vertices, %d edges", len(g.Vertices()), len(g.Edges()))
+}
+
+//caret prints the expression, and a caret under the position of the error
+func caret(exp string, err error) string {
+	perr, ok := err.(*gogrex.ParseError)
+	if !ok || strings.Contains(exp, "\n") {
+		return fmt.Sprintf("error: %s\n", err)
+	}
+	// keep the tabs, so that the caret is aligned
+	indent := strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return r
+		}
+		return ' '
+	}, exp[:perr.Pos])
+	return fmt.Sprintf("  %s\n  %s^ %s\n", exp, indent, perr.Msg)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRepl(t *testing.T) {
+	script := `a, (b | c)*
+a b c
+b
+:e a,
+:e x?
+:match
+:expected
+:shortest
+!6
+:expected a
+:history
+:quit
+`
+	code, stdout, _ := run(t, script, "repl")
+	if code != exitOK {
+		t.Fatalf("unexpected exit code %d", code)
+	}
+	for _, want := range []string{
+		"4 vertices, 7 edges: a, (b | c)*",
+		"grex> accepted",
+		`rejected: unexpected "b" after the empty sequence, expected one of: a`,
+		"  a,\n   ^ missing operand for \",\"",
+		"2 vertices, 1 edges: x?",
+		"x (or the end of the sequence)",
+		"grex> the empty sequence\n",
+		":match\naccepted",
+		`"a" is not expected`,
+		"   9  :match\n  10  :expected a\n",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("missing %q in\n%s", want, stdout)
+		}
+	}
+}
+
+func TestReplHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "history")
+
+	// an empty file is an empty history
+	ioutil.WriteFile(file, nil, 0644)
+	if _, stdout, _ := run(t, "!!\n", "repl", "-history", file); !strings.Contains(stdout, "no line 0 in the history") {
+		t.Errorf("unexpected history\n%s", stdout)
+	}
+	run(t, "a | b\n\nb\n", "repl", "-history", file)
+	if _, stdout, _ := run(t, "!!\n", "repl", "-history", file); !strings.Contains(stdout, "2 vertices, 1 edges: b") {
+		t.Errorf("the history was not loaded\n%s", stdout)
+	}
+	data, _ := ioutil.ReadFile(file)
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); !reflect.DeepEqual(lines, []string{"a | b", "b", "b"}) {
+		t.Errorf("unexpected history file %q", lines)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"ericaro.net/gogrex"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"unicode/utf8"
+)
+
+// grex serve runs a small playground: a single page, and a JSON api to draw an expression and match sequences.
+// Everything is served by the tool itself, it works offline.
+//
+//	POST /api/graph  {"expr": "a, b*"}                      -> {"canonical", "vertices", "edges", "svg", "dot"}
+//	POST /api/match  {"expr": "a, b*", "symbols": ["a"]}   -> {"accepted", "failed", "expected"}
+//
+// Invalid expressions, and expressions whose graph exceeds the limits, are answered with the status 400 and
+// {"error": {"message", "pos", "char"}}, pos is a byte offset in the expression, char the index of the character, both
+// -1 when the error has no position. Requests larger than 1MB get a 413, and canceled requests a 503.
+//
+// The api is anonymous: the limits keep every request to a few milliseconds. The layout does not check the request
+// context, so only small graphs are drawn.
+
+//maxRequest is the maximum size of a request body
+const maxRequest = 1 << 20
+
+//limits bound the graphs built for a request
+var limits = gogrex.Limits{MaxVertices: 10000, MaxEdges: 20000, MaxDepth: 100, MaxLength: 4096}
+
+//drawLimits bound the graphs drawn in SVG
+var drawLimits = gogrex.Limits{MaxVertices: 200, MaxEdges: 1000}
+
+func serve(args []string) int {
+	fs := flag.NewFlagSet("grex serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	fs.Parse(args)
+	log.Printf("grex playground on http://%s/", *addr)
+	if err := http.ListenAndServe(*addr, playground()); err != nil {
+		return fail("%s", err)
+	}
+	return exitOK
+}
+
+//playground returns the handler of the whole playground
+func playground() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(playgroundPage))
+	})
+	mux.HandleFunc("/api/graph", api(graphRequest))
+	mux.HandleFunc("/api/match", api(matchRequest))
+	return mux
+}
+
+//apiRequest is the body of every api request
+type apiRequest struct {
+	Expr    string   `json:"expr"`
+	Symbols []string `json:"symbols"`
+}
+
+//apiError describes an invalid expression
+type apiError struct {
+	Message string `json:"message"`
+	Pos     int    `json:"pos"`  // byte offset, -1 if unknown
+	Char    int    `json:"char"` // character index, -1 if unknown
+}
+
+//api turns a function of the request into a JSON handler
+func api(handle func(req apiRequest, g *gogrex.Grex, e *gogrex.Expr) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req apiRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequest)).Decode(&req); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if len(req.Expr) > limits.MaxLength {
+			badRequest(w, &gogrex.ErrLimitExceeded{Limit: "MaxLength", Max: limits.MaxLength, Value: len(req.Expr)})
+			return
+		}
+		e, err := gogrex.ParseExpr(req.Expr)
+		if err != nil {
+			if perr, ok := err.(*gogrex.ParseError); ok {
+				w.WriteHeader(http.StatusBadRequest)
+				failure := apiError{perr.Msg, perr.Pos, utf8.RuneCountInString(req.Expr[:perr.Pos])}
+				json.NewEncoder(w).Encode(map[string]apiError{"error": failure})
+				return
+			}
+			badRequest(w, err)
+			return
+		}
+		var m gogrex.StringManager
+		g, err := e.GrexContext(r.Context(), &m, limits)
+		if err == context.Canceled || err == context.DeadlineExceeded {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			badRequest(w, err)
+			return
+		}
+		response, err := handle(req, g, e)
+		if _, ok := err.(*gogrex.ErrLimitExceeded); ok {
+			badRequest(w, err)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+//badRequest answers an error without position
+func badRequest(w http.ResponseWriter, err error) {
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]apiError{"error": {err.Error(), -1, -1}})
+}
+
+func graphRequest(req apiRequest, g *gogrex.Grex, e *gogrex.Expr) (interface{}, error) {
+	if v := len(g.Vertices()); v > drawLimits.MaxVertices {
+		return nil, &gogrex.ErrLimitExceeded{Limit: "MaxVertices", Max: drawLimits.MaxVertices, Value: v}
+	}
+	if n := len(g.Edges()); n > drawLimits.MaxEdges {
+		return nil, &gogrex.ErrLimitExceeded{Limit: "MaxEdges", Max: drawLimits.MaxEdges, Value: n}
+	}
+	var svg bytes.Buffer
+	if err := g.WriteSVG(&svg); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"canonical": e.String(),
+		"vertices":  len(g.Vertices()),
+		"edges":     len(g.Edges()),
+		"svg":       svg.String(),
+		"dot":       g.String(),
+	}, nil
+}
+
+func matchRequest(req apiRequest, g *gogrex.Grex, e *gogrex.Expr) (interface{}, error) {
+	m := g.NewMatcher()
+	failed := -1 // index of the first unexpected symbol, len(symbols) for an incomplete sequence
+	for i, symbol := range req.Symbols {
+		if !m.Next(symbol) {
+			failed = i
+			break
+		}
+	}
+	if failed < 0 && !m.Accepts() {
+		failed = len(req.Symbols)
+	}
+	return map[string]interface{}{
+		"accepted": failed < 0,
+		"failed":   failed,
+		"expected": m.Expected(),
+	}, nil
+}
+
+const playgroundPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>grex playground</title>
+<style>
+body { font-family: sans-serif; margin: 2em; max-width: 60em; }
+textarea, input { font-family: monospace; font-size: 1em; width: 100%; box-sizing: border-box; }
+pre { background: #f4f4f4; padding: .5em; overflow: auto; }
+.error { color: #b00; }
+.ok { color: #080; }
+#graph svg { max-width: 100%; height: auto; }
+</style>
+</head>
+<body>
+<h1>grex playground</h1>
+<p>An expression of symbols: <code>,</code> for sequences, <code>|</code> for choices, <code>*</code>, <code>+</code>
+and <code>?</code> for repetitions, and parenthesis.</p>
+<textarea id="expr" rows="3">name, alias?, (telephone | email)+</textarea>
+<p><button id="draw">Draw</button> <span id="info"></span></p>
+<pre id="error" class="error" hidden></pre>
+<div id="graph"></div>
+<details><summary>dot</summary><pre id="dot"></pre></details>
+<h2>Match</h2>
+<p>Symbols, separated by spaces or commas:</p>
+<input id="symbols" value="name telephone email">
+<p><button id="match">Match</button> <span id="result"></span></p>
+<script>
+"use strict";
+const $ = id => document.getElementById(id);
+
+async function post(url, body) {
+  const response = await fetch(url, {method: "POST", headers: {"Content-Type": "application/json"}, body: JSON.stringify(body)});
+  return response.json();
+}
+
+function showError(expr, error) {
+  const lines = [error.message];
+  if (error.char >= 0) {
+    const chars = Array.from(expr);
+    lines.unshift(chars.join(""), chars.slice(0, error.char).map(c => c === "\t" ? "\t" : " ").join("") + "^");
+  }
+  $("error").textContent = lines.join("\n");
+  $("error").hidden = false;
+}
+
+async function draw() {
+  const expr = $("expr").value;
+  const r = await post("/api/graph", {expr});
+  $("error").hidden = true;
+  if (r.error) {
+    showError(expr, r.error);
+    $("graph").innerHTML = "";
+    $("dot").textContent = "";
+    $("info").textContent = "";
+    return;
+  }
+  $("graph").innerHTML = r.svg;
+  $("dot").textContent = r.dot;
+  $("info").textContent = r.vertices + " vertices, " + r.edges + " edges: " + r.canonical;
+}
+
+async function match() {
+  const expr = $("expr").value;
+  const symbols = $("symbols").value.split(/[\s,]+/).filter(s => s !== "");
+  const r = await post("/api/match", {expr, symbols});
+  const result = $("result");
+  if (r.error) {
+    showError(expr, r.error);
+    result.textContent = "";
+    return;
+  }
+  const expected = r.expected.length ? "expected one of: " + r.expected.join(" ") : "nothing expected";
+  if (r.accepted) {
+    result.className = "ok";
+    result.textContent = "accepted";
+  } else if (r.failed < symbols.length) {
+    result.className = "error";
+    result.textContent = "unexpected \"" + symbols[r.failed] + "\" at position " + (r.failed + 1) + ", " + expected;
+  } else {
+    result.className = "error";
+    result.textContent = "incomplete, " + expected;
+  }
+}
+
+$("draw").onclick = draw;
+$("match").onclick = match;
+draw();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+//post sends a request to the playground, and decodes the JSON response
+func post(t *testing.T, ctx context.Context, url, body string) (int, map[string]interface{}) {
+	req := httptest.NewRequest(http.MethodPost, url, strings.NewReader(body)).WithContext(ctx)
+	w := httptest.NewRecorder()
+	playground().ServeHTTP(w, req)
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w.Code, response
+}
+
+func TestServe(t *testing.T) {
+	ctx := context.Background()
+	code, r := post(t, ctx, "/api/graph", `{"expr": "a ,b*"}`)
+	if code != http.StatusOK || r["canonical"] != "a, b*" || r["vertices"] != 3.0 || !strings.HasPrefix(r["svg"].(string), "<?xml") {
+		t.Errorf("unexpected graph %d %v", code, r)
+	}
+	code, r = post(t, ctx, "/api/match", `{"expr": "a, b*", "symbols": ["a", "c"]}`)
+	if code != http.StatusOK || r["accepted"] != false || r["failed"] != 1.0 || !reflect.DeepEqual(r["expected"], []interface{}{"b"}) {
+		t.Errorf("unexpected match %d %v", code, r)
+	}
+
+	// the error is positioned in bytes and characters
+	code, r = post(t, ctx, "/api/graph", `{"expr": "é, ê ê"}`)
+	failure, _ := r["error"].(map[string]interface{})
+	if code != http.StatusBadRequest || failure["message"] != "missing operator" || failure["pos"] != 4.0 || failure["char"] != 3.0 {
+		t.Errorf("unexpected error %d %v", code, r)
+	}
+	// the graph exceeds the limits
+	deep := strings.Repeat("(", 200) + "a" + strings.Repeat(")*", 200)
+	code, r = post(t, ctx, "/api/graph", `{"expr": "`+deep+`"}`)
+	failure, _ = r["error"].(map[string]interface{})
+	if code != http.StatusBadRequest || !strings.Contains(failure["message"].(string), "MaxDepth") || failure["pos"] != -1.0 {
+		t.Errorf("unexpected error %d %v", code, r)
+	}
+
+	// the graph is within the limits, but its building copies a lot, and it is too large to draw
+	var b strings.Builder
+	b.WriteString("(b0")
+	for i := 1; i < 50; i++ {
+		fmt.Fprintf(&b, "|b%d", i)
+	}
+	b.WriteString(")+")
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&b, ", c%d", i)
+	}
+	start := time.Now()
+	code, r = post(t, ctx, "/api/graph", `{"expr": "`+b.String()+`"}`)
+	failure, _ = r["error"].(map[string]interface{})
+	if code != http.StatusBadRequest || !strings.Contains(failure["message"].(string), "MaxVertices") {
+		t.Errorf("unexpected error %d %v", code, r)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the request took %s", elapsed)
+	}
+	if code, r := post(t, ctx, "/api/match", `{"expr": "`+b.String()+`", "symbols": ["b1", "c0"]}`); code != http.StatusOK || r["failed"] != 2.0 {
+		t.Errorf("unexpected match %d %v", code, r)
+	}
+	code, r = post(t, ctx, "/api/graph", `{"expr": "`+strings.Repeat("a, ", 2000)+`a"}`)
+	failure, _ = r["error"].(map[string]interface{})
+	if code != http.StatusBadRequest || !strings.Contains(failure["message"].(string), "MaxLength") {
+		t.Errorf("unexpected error %d %v", code, r)
+	}
+
+	if code, _ := post(t, ctx, "/api/graph", `{"expr": "`+strings.Repeat("a, ", maxRequest/3)+`a"}`); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected the status %d for a large request, got %d", http.StatusRequestEntityTooLarge, code)
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if code, _ := post(t, canceled, "/api/graph", `{"expr": "a"}`); code != http.StatusServiceUnavailable {
+		t.Errorf("expected the status %d for a canceled request, got %d", http.StatusServiceUnavailable, code)
+	}
+	if code, _ := post(t, ctx, "/api/graph", `{"expr":`); code != http.StatusBadRequest {
+		t.Errorf("expected the status %d for invalid JSON, got %d", http.StatusBadRequest, code)
+	}
+
+	w := httptest.NewRecorder()
+	playground().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/graph", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected the status %d for a GET, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+	w = httptest.NewRecorder()
+	playground().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "grex playground") {
+		t.Errorf("unexpected page %d", w.Code)
+	}
+}